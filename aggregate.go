@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/eclesh/welford"
+)
+
+// Aggregate ops come in two forms. The whole-stack form (sum, avg, sd, ...)
+// reads every value, leaves the stack in place and pushes its result on top,
+// so several statistics can be taken of the same data. When a vector or
+// matrix is on top it reads that instead of the stack. The window form (nsum,
+// navg, nsd, ...) pops a count n and then consumes exactly the top n values,
+// the same way every other op consumes its operands.
+
+func popWindow(stack *Stack) ([]float64, error) {
+	top, err := stack.Top()
+	if err != nil {
+		return nil, err
+	}
+	n := int(top)
+	if float64(n) != top || n < 1 {
+		return nil, fmt.Errorf("window size must be a positive integer")
+	}
+	if stack.Len() < n+1 {
+		return nil, fmt.Errorf("insufficient stack")
+	}
+	_ = stack.PopU()
+	return stack.PopR(n)
+}
+
+func summarize(data []float64) *welford.Stats {
+	stats := welford.New()
+	for _, n := range data {
+		stats.Add(n)
+	}
+	return stats
+}
+
+func sum(data []float64) (float64, error) {
+	result := 0.0
+	for _, n := range data {
+		result += n
+	}
+	return result, nil
+}
+
+func mean(data []float64) (float64, error) {
+	return summarize(data).Mean(), nil
+}
+
+func stddev(data []float64) (float64, error) {
+	if len(data) <= 1 {
+		return 0, nil
+	}
+	return summarize(data).Stddev(), nil
+}
+
+func variance(data []float64) (float64, error) {
+	return summarize(data).Variance(), nil
+}
+
+func minimum(data []float64) (float64, error) {
+	return summarize(data).Min(), nil
+}
+
+func maximum(data []float64) (float64, error) {
+	return summarize(data).Max(), nil
+}
+
+func spread(data []float64) (float64, error) {
+	if len(data) < 1 {
+		return 0.0, fmt.Errorf("insufficient stack")
+	}
+	stats := summarize(data)
+	return stats.Max() - stats.Min(), nil
+}
+
+var (
+	nsumOp    = wrapWindowOp("n nsum consumes the top n values and pushes their sum", sum)
+	navgOp    = wrapWindowOp("n navg consumes the top n values and pushes their mean", mean)
+	nsdOp     = wrapWindowOp("n nsd consumes the top n values and pushes their standard deviation", stddev)
+	nvarOp    = wrapWindowOp("n nvar consumes the top n values and pushes their variance", variance)
+	nminOp    = wrapWindowOp("n nmin consumes the top n values and pushes their minimum", minimum)
+	nmaxOp    = wrapWindowOp("n nmax consumes the top n values and pushes their maximum", maximum)
+	nrangeOp  = wrapWindowOp("n nrange consumes the top n values and pushes max - min", spread)
+	nmedianOp = wrapWindowOp("n nmedian consumes the top n values and pushes their median", median)
+	nmodeOp   = wrapWindowOp("n nmode consumes the top n values and pushes the most frequent one", mode)
+	nmadOp    = wrapWindowOp("n nmad consumes the top n values and pushes their median absolute deviation", medianAbsDev)
+	ngmeanOp  = wrapWindowOp("n ngmean consumes the top n values and pushes their geometric mean", geometricMean)
+	nhmeanOp  = wrapWindowOp("n nhmean consumes the top n values and pushes their harmonic mean", harmonicMean)
+
+	nsortOp = Op{
+		"n nsort sorts the top n values in place, largest on top",
+		func(stack *Stack) (Floats, error) {
+			window, err := popWindow(stack)
+			if err != nil {
+				return nil, err
+			}
+			sorted, err := sortedCopy(window)
+			if err != nil {
+				return nil, err
+			}
+			slices.Reverse(sorted)
+			return sorted, nil
+		},
+	}
+)
+
+func wrapAggregateOp(doc string, f func([]float64) (float64, error)) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			data, err := stack.Data()
+			if err != nil {
+				return nil, err
+			}
+			result, err := f(data)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+}
+
+func wrapWindowOp(doc string, f func([]float64) (float64, error)) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			window, err := popWindow(stack)
+			if err != nil {
+				return nil, err
+			}
+			result, err := f(window)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowConsumes(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 100, 1, 2, 3, 4, 3)
+	err := ops.Run("nsum", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{100, 1, 9}, scalars(t, stack))
+}
+
+func TestWindowStats(t *testing.T) {
+	cases := []struct {
+		op       string
+		expected float64
+	}{
+		{"navg", 5},
+		{"nsd", 2.138089935299395},
+		{"nvar", 4.571428571428571},
+		{"nmin", 2},
+		{"nmax", 9},
+		{"nrange", 7},
+		{"nmedian", 4.5},
+		{"nmode", 4},
+	}
+	for _, c := range cases {
+		stack := NewStack()
+		ops := NewOps()
+		pushAll(stack, -50, 2, 4, 4, 4, 5, 5, 7, 9, 8)
+		err := ops.Run(c.op, stack)
+		assert.Nil(t, err, c.op)
+		assert.Equal(t, 2, stack.Len(), c.op)
+		assertClose(t, c.expected, topOf(t, stack))
+	}
+}
+
+func TestWindowSort(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 10, 3, 1, 2, 3)
+	err := ops.Run("nsort", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{10, 1, 2, 3}, scalars(t, stack))
+}
+
+func TestWindowPercentile(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1000, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 10, 25)
+	err := ops.Run("npct", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, stack.Len())
+	assertClose(t, 3.25, topOf(t, stack))
+}
+
+func TestWindowInvalid(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 3)
+	assert.NotNil(t, ops.Run("nsum", stack))
+	assert.Equal(t, 3, stack.Len())
+
+	stack.Push(1.5)
+	assert.NotNil(t, ops.Run("nsum", stack))
+	assert.Equal(t, 4, stack.Len())
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The International Standard Atmosphere from ISO 2533, from 2 km below sea
+// level up to the stratopause at 51 km. Altitudes are geopotential, which is
+// what pressure altitude and the altimeter use. The altitude ops take feet,
+// as pr always has, and their m variants take meters. Results are in degrees
+// Celsius, hPa, kg/m³, m/s and Pa·s; pr stays in inHg.
+
+const (
+	_isaGravity         = 9.80665
+	_isaGasConstant     = 287.05287
+	_isaGamma           = 1.4
+	_isaSeaLevelTemp    = 288.15
+	_isaSeaLevelPress   = 101325.0
+	_isaMinAltitude     = -2000.0
+	_isaMaxAltitude     = 51000.0
+	_sutherlandConstant = 1.458e-6
+	_sutherlandTemp     = 110.4
+)
+
+// _isaLayers holds each layer's base altitude in meters and its lapse rate
+// in kelvin per meter.
+var _isaLayers = []struct {
+	base  float64
+	lapse float64
+}{
+	{_isaMinAltitude, -0.0065},
+	{11000, 0},
+	{20000, 0.001},
+	{32000, 0.0028},
+	{47000, 0},
+}
+
+type Atmosphere struct {
+	temperature float64
+	pressure    float64
+	density     float64
+}
+
+func checkAltitude(h float64) error {
+	if h < _isaMinAltitude || h > _isaMaxAltitude || math.IsNaN(h) {
+		return fmt.Errorf("altitude %g m is outside the standard atmosphere, %g m to %g m", h, _isaMinAltitude, _isaMaxAltitude)
+	}
+	return nil
+}
+
+// isa walks up the layers from sea level conditions at h = 0 and returns
+// the temperature in kelvin, pressure in Pa and density in kg/m³ at h meters.
+func isa(h float64) (Atmosphere, error) {
+	err := checkAltitude(h)
+	if err != nil {
+		return Atmosphere{}, err
+	}
+	// sea level lies in the first layer, so climb or descend from there
+	temperature := _isaSeaLevelTemp
+	pressure := _isaSeaLevelPress
+	base := 0.0
+	for i, layer := range _isaLayers {
+		top := _isaMaxAltitude
+		if i+1 < len(_isaLayers) {
+			top = _isaLayers[i+1].base
+		}
+		end := min(h, top)
+		temperature, pressure = isaStep(temperature, pressure, layer.lapse, end-base)
+		base = end
+		if h <= top {
+			break
+		}
+	}
+	return Atmosphere{temperature, pressure, pressure / (_isaGasConstant * temperature)}, nil
+}
+
+// isaStep climbs dh meters through a layer with a constant lapse rate.
+func isaStep(temperature, pressure, lapse, dh float64) (float64, float64) {
+	if lapse == 0 {
+		return temperature, pressure * math.Exp(-_isaGravity*dh/(_isaGasConstant*temperature))
+	}
+	next := temperature + lapse*dh
+	return next, pressure * math.Pow(next/temperature, -_isaGravity/(lapse*_isaGasConstant))
+}
+
+func speedOfSound(temperature float64) float64 {
+	return math.Sqrt(_isaGamma * _isaGasConstant * temperature)
+}
+
+// viscosity is Sutherland's law for the dynamic viscosity of air.
+func viscosity(temperature float64) float64 {
+	return _sutherlandConstant * math.Pow(temperature, 1.5) / (temperature + _sutherlandTemp)
+}
+
+// altitudeWhere finds the altitude in meters at which f of the standard
+// atmosphere falls to target. f must decrease with altitude.
+func altitudeWhere(target float64, f func(Atmosphere) float64) (float64, error) {
+	return brent(func(h float64) (float64, error) {
+		atmosphere, err := isa(h)
+		if err != nil {
+			return 0, err
+		}
+		return f(atmosphere) - target, nil
+	}, _isaMinAltitude, _isaMaxAltitude)
+}
+
+// pressureAltitude is the standard altitude in feet of the pressure at a
+// field, found from its elevation in feet and altimeter setting in Pa.
+func pressureAltitude(elevation, altimeter float64) (float64, error) {
+	station, err := stationPressure(elevation, altimeter)
+	if err != nil {
+		return 0, err
+	}
+	h, err := altitudeWhere(station, func(a Atmosphere) float64 { return a.pressure })
+	return h * _ftPerM, err
+}
+
+// stationPressure reduces an altimeter setting to the pressure at the field,
+// the altimeter setting being the sea level pressure of a standard
+// atmosphere that reads the field elevation.
+func stationPressure(elevation, altimeter float64) (float64, error) {
+	if altimeter <= 0 {
+		return 0, fmt.Errorf("altimeter setting must be positive")
+	}
+	h := elevation / _ftPerM
+	err := checkAltitude(h)
+	if err != nil {
+		return 0, err
+	}
+	lapse := _isaLayers[0].lapse
+	exponent := -_isaGravity / (lapse * _isaGasConstant)
+	return altimeter * math.Pow(1+lapse*h/_isaSeaLevelTemp, exponent), nil
+}
+
+// densityAltitude is the standard altitude in feet with the air density at
+// the field, given its elevation in feet, altimeter setting in Pa and
+// outside air temperature in degrees Celsius.
+func densityAltitude(elevation, altimeter, oat float64) (float64, error) {
+	station, err := stationPressure(elevation, altimeter)
+	if err != nil {
+		return 0, err
+	}
+	temperature := oat + _kelvinOffset
+	if temperature <= 0 {
+		return 0, fmt.Errorf("temperature %g °C is below absolute zero", oat)
+	}
+	density := station / (_isaGasConstant * temperature)
+	h, err := altitudeWhere(density, func(a Atmosphere) float64 { return a.density })
+	return h * _ftPerM, err
+}
+
+var (
+	isaTempOp       = wrapISAOp("temperature in °C", false, func(a Atmosphere) float64 { return a.temperature - _kelvinOffset })
+	isaTempMOp      = wrapISAOp("temperature in °C", true, func(a Atmosphere) float64 { return a.temperature - _kelvinOffset })
+	isaPressureOp   = wrapISAOp("pressure in hPa", false, func(a Atmosphere) float64 { return a.pressure / _paPerHPa })
+	isaPressureMOp  = wrapISAOp("pressure in hPa", true, func(a Atmosphere) float64 { return a.pressure / _paPerHPa })
+	isaDensityOp    = wrapISAOp("density in kg/m³", false, func(a Atmosphere) float64 { return a.density })
+	isaDensityMOp   = wrapISAOp("density in kg/m³", true, func(a Atmosphere) float64 { return a.density })
+	isaSoundOp      = wrapISAOp("speed of sound in m/s", false, func(a Atmosphere) float64 { return speedOfSound(a.temperature) })
+	isaSoundMOp     = wrapISAOp("speed of sound in m/s", true, func(a Atmosphere) float64 { return speedOfSound(a.temperature) })
+	isaViscosityOp  = wrapISAOp("dynamic viscosity in Pa·s", false, func(a Atmosphere) float64 { return viscosity(a.temperature) })
+	isaViscosityMOp = wrapISAOp("dynamic viscosity in Pa·s", true, func(a Atmosphere) float64 { return viscosity(a.temperature) })
+
+	isaOp = Op{
+		"print the standard atmosphere at stack.Top() feet",
+		func(stack *Stack) (Floats, error) {
+			feet, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
+			a, err := isa(feet / _ftPerM)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("altitude     %g ft, %g m\n", feet, feet/_ftPerM)
+			fmt.Printf("temperature  %.2f °C, %.2f K\n", a.temperature-_kelvinOffset, a.temperature)
+			fmt.Printf("pressure     %.2f hPa, %.2f inHg\n", a.pressure/_paPerHPa, a.pressure/_paPerInHg)
+			fmt.Printf("density      %.5f kg/m³\n", a.density)
+			fmt.Printf("sound        %.2f m/s\n", speedOfSound(a.temperature))
+			fmt.Printf("viscosity    %.4e Pa·s\n", viscosity(a.temperature))
+			return nil, nil
+		},
+	}
+
+	pressureAltitudeOp = Op{
+		"elevation altimeter palt, pressure altitude in feet from field elevation in feet and altimeter setting in inHg",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			result, err := pressureAltitude(elems[0], elems[1]*_paPerInHg)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	pressureAltitudeHPaOp = Op{
+		"elevation qnh palth, pressure altitude in feet from field elevation in feet and QNH in hPa",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			result, err := pressureAltitude(elems[0], elems[1]*_paPerHPa)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	densityAltitudeOp = Op{
+		"elevation altimeter oat dalt, density altitude in feet from field elevation in feet, altimeter setting in inHg and OAT in °C",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			result, err := densityAltitude(elems[0], elems[1]*_paPerInHg, elems[2])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	densityAltitudeHPaOp = Op{
+		"elevation qnh oat dalth, density altitude in feet from field elevation in feet, QNH in hPa and OAT in °C",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			result, err := densityAltitude(elems[0], elems[1]*_paPerHPa, elems[2])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+)
+
+func wrapISAOp(doc string, meters bool, f func(Atmosphere) float64) Op {
+	unit := "feet"
+	scale := 1 / _ftPerM
+	if meters {
+		unit = "meters"
+		scale = 1
+	}
+	return Op{
+		fmt.Sprintf("standard atmosphere %s at stack.Top() %s", doc, unit),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			a, err := isa(top * scale)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{f(a)}, nil
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISASeaLevel(t *testing.T) {
+	a, err := isa(0)
+	assert.Nil(t, err)
+	assert.InDelta(t, 288.15, a.temperature, 1e-9)
+	assert.InDelta(t, 101325, a.pressure, 1e-6)
+	assert.InDelta(t, 1.225, a.density, 1e-4)
+	assert.InDelta(t, 340.294, speedOfSound(a.temperature), 1e-3)
+	assert.InDelta(t, 1.7894e-5, viscosity(a.temperature), 1e-9)
+}
+
+func TestISALayers(t *testing.T) {
+	cases := []struct {
+		h           float64
+		temperature float64
+		pressure    float64
+	}{
+		{-2000, 301.15, 127774},
+		{11000, 216.65, 22632.1},
+		{20000, 216.65, 5474.89},
+		{32000, 228.65, 868.019},
+		{47000, 270.65, 110.906},
+		{51000, 270.65, 66.9389},
+	}
+	for _, c := range cases {
+		a, err := isa(c.h)
+		assert.Nil(t, err)
+		assert.InDelta(t, c.temperature, a.temperature, 1e-9, c.h)
+		assert.InEpsilon(t, c.pressure, a.pressure, 1e-5, c.h)
+	}
+
+	_, err := isa(52000)
+	assert.NotNil(t, err)
+	_, err = isa(-2001)
+	assert.NotNil(t, err)
+}
+
+func TestISAOps(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "36089", "isat")
+	assert.InDelta(t, -56.5, stack.PopU(), 1e-3)
+
+	runAll(t, stack, ops, "11000", "isatm")
+	assert.InDelta(t, -56.5, stack.PopU(), 1e-9)
+
+	runAll(t, stack, ops, "0", "isap")
+	assert.InDelta(t, 1013.25, stack.PopU(), 1e-9)
+
+	runAll(t, stack, ops, "11000", "isarhom")
+	assert.InDelta(t, 0.36392, stack.PopU(), 1e-5)
+
+	runAll(t, stack, ops, "11000", "isaam")
+	assert.InDelta(t, 295.07, stack.PopU(), 1e-2)
+
+	runAll(t, stack, ops, "0", "isamu")
+	assert.InDelta(t, 1.7894e-5, stack.PopU(), 1e-9)
+
+	assert.NotNil(t, ops.Run("isat", stack))
+	stack.Push(200000)
+	assert.NotNil(t, ops.Run("isat", stack))
+	assert.Equal(t, 200000.0, stack.PopU())
+}
+
+func TestPressureAltitude(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "5000", "29.9212524", "palt")
+	assert.InDelta(t, 5000, stack.PopU(), 1e-3)
+
+	// a higher altimeter setting means denser air and a lower pressure altitude
+	runAll(t, stack, ops, "5000", "30.42", "palt")
+	assert.InDelta(t, 4557.6, stack.PopU(), 0.1)
+
+	runAll(t, stack, ops, "1000", "1013.25", "palth")
+	assert.InDelta(t, 1000, stack.PopU(), 1e-3)
+
+	assert.NotNil(t, ops.Run("palt", stack))
+}
+
+func TestDensityAltitude(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	// at the standard temperature density altitude is pressure altitude
+	a, err := isa(5000 / _ftPerM)
+	assert.Nil(t, err)
+	stack.Push(5000)
+	stack.Push(29.9212524)
+	stack.Push(a.temperature - _kelvinOffset)
+	assert.Nil(t, ops.Run("dalt", stack))
+	assert.InDelta(t, 5000, stack.PopU(), 1e-3)
+
+	// a hot day adds roughly 120 ft per degree above standard
+	runAll(t, stack, ops, "5000", "29.92", "30", "dalt")
+	assert.InDelta(t, 7802, stack.PopU(), 1)
+
+	runAll(t, stack, ops, "0", "1013.25", "15", "dalth")
+	assert.InDelta(t, 0, stack.PopU(), 1e-3)
+
+	stack.Push(0)
+	stack.Push(29.92)
+	stack.Push(-300)
+	assert.NotNil(t, ops.Run("dalt", stack))
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// The ballistic solver flies a point mass through the standard atmosphere,
+// slowed by the G1 or G7 drag function scaled by the ballistic coefficient
+// and the air density. Its registers take imperial units like mil and mph:
+// muzzle velocity in fps, sight height in inches, ranges in yards, altitude
+// in feet, crosswind in mph with positive blowing left to right, and the
+// temperature in °C, standard for the altitude unless set. Holds are the
+// correction to apply, positive up and right, in mils or MOA.
+
+const (
+	_ftPerYd          = 3.0
+	_inPerFt          = 12.0
+	_fpsPerMph        = 5280.0 / 3600.0
+	_moaPerRad        = 180 * 60 / math.Pi
+	_defaultSight     = 1.5
+	_defaultZero      = 100
+	_maxFlightTime    = 30.0
+	_flightTimeStep   = 0.0005
+	_maxZeroAngle     = 0.2
+	_standardDensity  = _isaSeaLevelPress / (_isaGasConstant * _isaSeaLevelTemp)
+	_dragCoefficient  = math.Pi / 8 * 0.0764742 / 144
+	_gravityFtPerSec2 = _isaGravity * _ftPerM
+)
+
+type DragModel int
+
+const (
+	DragG1 DragModel = iota
+	DragG7
+)
+
+// dragPoint is a drag coefficient at a Mach number.
+type dragPoint struct {
+	mach float64
+	cd   float64
+}
+
+// _dragTables are the standard G1 and G7 projectile drag coefficients.
+var _dragTables = map[DragModel][]dragPoint{
+	DragG1: {
+		{0.00, 0.2629}, {0.05, 0.2558}, {0.10, 0.2487}, {0.15, 0.2413},
+		{0.20, 0.2344}, {0.25, 0.2278}, {0.30, 0.2214}, {0.35, 0.2155},
+		{0.40, 0.2104}, {0.45, 0.2061}, {0.50, 0.2032}, {0.55, 0.2020},
+		{0.60, 0.2034}, {0.70, 0.2165}, {0.725, 0.2230}, {0.75, 0.2313},
+		{0.775, 0.2417}, {0.80, 0.2546}, {0.825, 0.2706}, {0.85, 0.2901},
+		{0.875, 0.3136}, {0.90, 0.3415}, {0.925, 0.3734}, {0.95, 0.4084},
+		{0.975, 0.4448}, {1.00, 0.4805}, {1.025, 0.5136}, {1.05, 0.5427},
+		{1.075, 0.5677}, {1.10, 0.5883}, {1.125, 0.6053}, {1.15, 0.6191},
+		{1.20, 0.6393}, {1.25, 0.6518}, {1.30, 0.6589}, {1.35, 0.6621},
+		{1.40, 0.6625}, {1.45, 0.6607}, {1.50, 0.6573}, {1.55, 0.6528},
+		{1.60, 0.6474}, {1.65, 0.6413}, {1.70, 0.6347}, {1.75, 0.6280},
+		{1.80, 0.6210}, {1.85, 0.6141}, {1.90, 0.6072}, {1.95, 0.6003},
+		{2.00, 0.5934}, {2.05, 0.5867}, {2.10, 0.5804}, {2.15, 0.5743},
+		{2.20, 0.5685}, {2.25, 0.5630}, {2.30, 0.5577}, {2.35, 0.5527},
+		{2.40, 0.5481}, {2.45, 0.5438}, {2.50, 0.5397}, {2.60, 0.5325},
+		{2.70, 0.5264}, {2.80, 0.5211}, {2.90, 0.5168}, {3.00, 0.5133},
+		{3.10, 0.5105}, {3.20, 0.5084}, {3.30, 0.5067}, {3.40, 0.5054},
+		{3.50, 0.5040}, {3.60, 0.5030}, {3.70, 0.5022}, {3.80, 0.5016},
+		{3.90, 0.5010}, {4.00, 0.5006}, {4.20, 0.4998}, {4.40, 0.4995},
+		{4.60, 0.4992}, {4.80, 0.4990}, {5.00, 0.4988},
+	},
+	DragG7: {
+		{0.00, 0.1198}, {0.05, 0.1197}, {0.10, 0.1196}, {0.15, 0.1194},
+		{0.20, 0.1193}, {0.25, 0.1194}, {0.30, 0.1194}, {0.35, 0.1194},
+		{0.40, 0.1193}, {0.45, 0.1193}, {0.50, 0.1194}, {0.55, 0.1193},
+		{0.60, 0.1194}, {0.65, 0.1197}, {0.70, 0.1202}, {0.725, 0.1207},
+		{0.75, 0.1215}, {0.775, 0.1226}, {0.80, 0.1242}, {0.825, 0.1266},
+		{0.85, 0.1306}, {0.875, 0.1368}, {0.90, 0.1464}, {0.925, 0.1660},
+		{0.95, 0.2054}, {0.975, 0.2993}, {1.00, 0.3803}, {1.025, 0.4015},
+		{1.05, 0.4043}, {1.075, 0.4034}, {1.10, 0.4014}, {1.125, 0.3987},
+		{1.15, 0.3955}, {1.20, 0.3884}, {1.25, 0.3810}, {1.30, 0.3732},
+		{1.35, 0.3657}, {1.40, 0.3580}, {1.50, 0.3440}, {1.55, 0.3376},
+		{1.60, 0.3315}, {1.65, 0.3260}, {1.70, 0.3209}, {1.75, 0.3160},
+		{1.80, 0.3117}, {1.85, 0.3078}, {1.90, 0.3042}, {1.95, 0.3010},
+		{2.00, 0.2980}, {2.05, 0.2951}, {2.10, 0.2922}, {2.15, 0.2892},
+		{2.20, 0.2864}, {2.25, 0.2835}, {2.30, 0.2807}, {2.35, 0.2779},
+		{2.40, 0.2752}, {2.45, 0.2725}, {2.50, 0.2697}, {2.55, 0.2670},
+		{2.60, 0.2643}, {2.65, 0.2615}, {2.70, 0.2588}, {2.75, 0.2561},
+		{2.80, 0.2533}, {2.85, 0.2506}, {2.90, 0.2479}, {2.95, 0.2451},
+		{3.00, 0.2424}, {3.10, 0.2368}, {3.20, 0.2313}, {3.30, 0.2258},
+		{3.40, 0.2205}, {3.50, 0.2154}, {3.60, 0.2106}, {3.70, 0.2060},
+		{3.80, 0.2017}, {3.90, 0.1975}, {4.00, 0.1935}, {4.20, 0.1861},
+		{4.40, 0.1793}, {4.60, 0.1730}, {4.80, 0.1672}, {5.00, 0.1618},
+	},
+}
+
+func (m DragModel) String() string {
+	if m == DragG7 {
+		return "G7"
+	}
+	return "G1"
+}
+
+// cd interpolates the drag coefficient at mach linearly, holding the end
+// values beyond the table.
+func (m DragModel) cd(mach float64) float64 {
+	table := _dragTables[m]
+	k := sort.Search(len(table), func(i int) bool { return table[i].mach >= mach })
+	switch k {
+	case 0:
+		return table[0].cd
+	case len(table):
+		return table[len(table)-1].cd
+	}
+	lo, hi := table[k-1], table[k]
+	return lo.cd + (hi.cd-lo.cd)*(mach-lo.mach)/(hi.mach-lo.mach)
+}
+
+type Ballistics struct {
+	velocity    float64
+	bc          float64
+	model       DragModel
+	sight       float64
+	zero        float64
+	altitude    float64
+	temperature float64
+	customTemp  bool
+	wind        float64
+	moa         bool
+}
+
+func NewBallistics() Ballistics {
+	return Ballistics{sight: _defaultSight, zero: _defaultZero}
+}
+
+// trajectoryPoint is where the bullet is when it reaches a range, in feet
+// above the bore line's origin, with its speed and time of flight.
+type trajectoryPoint struct {
+	x float64
+	y float64
+	v float64
+	t float64
+}
+
+// Hold is the correction for one range, with the path below the line of
+// sight and the wind drift in inches.
+type Hold struct {
+	yards     float64
+	path      float64
+	elevation float64
+	drift     float64
+	windage   float64
+	velocity  float64
+	time      float64
+}
+
+func (b Ballistics) check() error {
+	switch {
+	case b.velocity <= 0:
+		return fmt.Errorf("set a positive muzzle velocity with mv")
+	case b.bc <= 0:
+		return fmt.Errorf("set a positive ballistic coefficient with bc")
+	case b.zero <= 0:
+		return fmt.Errorf("the zero range must be positive")
+	}
+	return nil
+}
+
+// air returns the density relative to the standard the ballistic
+// coefficients are measured in and the speed of sound in fps.
+func (b Ballistics) air() (float64, float64, error) {
+	a, err := isa(b.altitude / _ftPerM)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b.customTemp {
+		a.temperature = b.temperature + _kelvinOffset
+		if a.temperature <= 0 {
+			return 0, 0, fmt.Errorf("temperature %g °C is below absolute zero", b.temperature)
+		}
+		a.density = a.pressure / (_isaGasConstant * a.temperature)
+	}
+	return a.density / _standardDensity, speedOfSound(a.temperature) * _ftPerM, nil
+}
+
+// fly integrates the trajectory with fourth order Runge-Kutta steps from a
+// bore elevated by angle radians and records where it crosses each of the
+// ascending ranges in feet.
+func (b Ballistics) fly(angle float64, ranges []float64) ([]trajectoryPoint, error) {
+	density, sound, err := b.air()
+	if err != nil {
+		return nil, err
+	}
+	accel := func(s [4]float64) [4]float64 {
+		v := math.Hypot(s[2], s[3])
+		k := density * b.model.cd(v/sound) * _dragCoefficient / b.bc
+		return [4]float64{s[2], s[3], -k * v * s[2], -k*v*s[3] - _gravityFtPerSec2}
+	}
+	step := func(s [4]float64, h float64, d [4]float64) [4]float64 {
+		for i := range s {
+			s[i] += h * d[i]
+		}
+		return s
+	}
+	state := [4]float64{0, 0, b.velocity * math.Cos(angle), b.velocity * math.Sin(angle)}
+	points := make([]trajectoryPoint, 0, len(ranges))
+	dt := _flightTimeStep
+	for t := 0.0; len(points) < len(ranges); t += dt {
+		if t > _maxFlightTime || state[2] <= 0 {
+			return nil, fmt.Errorf("the bullet does not reach %g yd", ranges[len(points)]/_ftPerYd)
+		}
+		k1 := accel(state)
+		k2 := accel(step(state, dt/2, k1))
+		k3 := accel(step(state, dt/2, k2))
+		k4 := accel(step(state, dt, k3))
+		next := state
+		for i := range next {
+			next[i] += dt / 6 * (k1[i] + 2*k2[i] + 2*k3[i] + k4[i])
+		}
+		for len(points) < len(ranges) && next[0] >= ranges[len(points)] {
+			x := ranges[len(points)]
+			f := (x - state[0]) / (next[0] - state[0])
+			lerp := func(i int) float64 { return state[i] + f*(next[i]-state[i]) }
+			points = append(points, trajectoryPoint{x, lerp(1), math.Hypot(lerp(2), lerp(3)), t + f*dt})
+		}
+		state = next
+	}
+	return points, nil
+}
+
+// zeroAngle is the bore elevation that puts the bullet on the line of sight
+// at the zero range.
+func (b Ballistics) zeroAngle() (float64, error) {
+	sight := b.sight / _inPerFt
+	zero := b.zero * _ftPerYd
+	return brent(func(angle float64) (float64, error) {
+		points, err := b.fly(angle, []float64{zero})
+		if err != nil {
+			return 0, err
+		}
+		return points[0].y - sight, nil
+	}, 0, _maxZeroAngle)
+}
+
+func (b Ballistics) angular(radians float64) float64 {
+	if b.moa {
+		return radians * _moaPerRad
+	}
+	return radians * 1000
+}
+
+func (b Ballistics) unit() string {
+	if b.moa {
+		return "MOA"
+	}
+	return "mil"
+}
+
+// holds solves the trajectory at each of the ascending ranges in yards.
+// Wind drift follows Didion's lag rule, the crosswind times the time lost to
+// drag.
+func (b Ballistics) holds(yards []float64) ([]Hold, error) {
+	err := b.check()
+	if err != nil {
+		return nil, err
+	}
+	angle, err := b.zeroAngle()
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]float64, len(yards))
+	for k, y := range yards {
+		ranges[k] = y * _ftPerYd
+	}
+	points, err := b.fly(angle, ranges)
+	if err != nil {
+		return nil, err
+	}
+	holds := make([]Hold, len(points))
+	for k, p := range points {
+		path := p.y - b.sight/_inPerFt
+		drift := b.wind * _fpsPerMph * (p.t - p.x/(b.velocity*math.Cos(angle)))
+		holds[k] = Hold{
+			yards:     yards[k],
+			path:      path * _inPerFt,
+			elevation: b.angular(-math.Atan2(path, p.x)),
+			drift:     drift * _inPerFt,
+			windage:   b.angular(-math.Atan2(drift, p.x)),
+			velocity:  p.v,
+			time:      p.t,
+		}
+	}
+	return holds, nil
+}
+
+func (b Ballistics) String() string {
+	temperature := "standard"
+	if b.customTemp {
+		temperature = fmt.Sprintf("%g °C", b.temperature)
+	}
+	return fmt.Sprintf("mv %g fps  bc %g %s  sight %g in  zero %g yd  alt %g ft  temp %s  wind %g mph  (%s)",
+		b.velocity, b.bc, b.model, b.sight, b.zero, b.altitude, temperature, b.wind, b.unit())
+}
+
+var (
+	ballisticVelocityOp = wrapStoreBallisticsOp("mv", "muzzle velocity in fps", func(b *Ballistics) *float64 { return &b.velocity })
+	ballisticBCOp       = wrapStoreBallisticsOp("bc", "ballistic coefficient in lb/in² for the drag model", func(b *Ballistics) *float64 { return &b.bc })
+	ballisticSightOp    = wrapStoreBallisticsOp("sight", "sight height above the bore in inches", func(b *Ballistics) *float64 { return &b.sight })
+	ballisticZeroOp     = wrapStoreBallisticsOp("zero", "zero range in yards", func(b *Ballistics) *float64 { return &b.zero })
+	ballisticAltitudeOp = wrapStoreBallisticsOp("balt", "altitude in feet", func(b *Ballistics) *float64 { return &b.altitude })
+	ballisticWindOp     = wrapStoreBallisticsOp("wind", "crosswind in mph, positive blowing left to right", func(b *Ballistics) *float64 { return &b.wind })
+
+	ballisticG1Op = wrapDragModelOp("use the G1 drag model for flat based bullets", DragG1)
+	ballisticG7Op = wrapDragModelOp("use the G7 drag model for boat tail bullets", DragG7)
+
+	ballisticTempOp = Op{
+		"store stack.Top() as the ballistic temperature in °C instead of the standard temperature",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			stack.ballistics.temperature = top
+			stack.ballistics.customTemp = true
+			return nil, nil
+		},
+	}
+
+	ballisticMilsOp = Op{
+		"give ballistic holds in milliradians",
+		func(stack *Stack) (Floats, error) {
+			stack.ballistics.moa = false
+			return nil, nil
+		},
+	}
+
+	ballisticMOAOp = Op{
+		"give ballistic holds in minutes of angle",
+		func(stack *Stack) (Floats, error) {
+			stack.ballistics.moa = true
+			return nil, nil
+		},
+	}
+
+	ballisticShowOp = Op{
+		"print the ballistic registers",
+		func(stack *Stack) (Floats, error) {
+			fmt.Println(stack.ballistics)
+			return nil, nil
+		},
+	}
+
+	ballisticClearOp = Op{
+		"clear the ballistic registers, keeping the hold unit",
+		func(stack *Stack) (Floats, error) {
+			b := NewBallistics()
+			b.moa = stack.ballistics.moa
+			stack.ballistics = b
+			return nil, nil
+		},
+	}
+
+	rangeCardOp = Op{
+		"max step card, print a range card every step yards out to max yards",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			farthest, step := elems[0], elems[1]
+			if step <= 0 || farthest < step {
+				return nil, fmt.Errorf("need a positive step no longer than the maximum range")
+			}
+			count, err := checkSequenceLength(math.Floor(farthest/step + 1e-9))
+			if err != nil {
+				return nil, err
+			}
+			yards := make([]float64, count)
+			for k := range yards {
+				yards[k] = float64(k+1) * step
+			}
+			holds, err := stack.ballistics.holds(yards)
+			if err != nil {
+				return nil, err
+			}
+			unit := stack.ballistics.unit()
+			fmt.Printf("%6s %9s %8s %9s %8s %7s %6s\n", "yd", "path in", unit, "drift in", unit, "fps", "s")
+			for _, h := range holds {
+				fmt.Printf("%6g %9.1f %8.2f %9.1f %8.2f %7.0f %6.3f\n",
+					h.yards, h.path, h.elevation, h.drift, h.windage, h.velocity, h.time)
+			}
+			return nil, nil
+		},
+	}
+
+	holdOp = Op{
+		"yards hold, push the windage then the elevation hold for a range",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if top <= 0 {
+				return nil, fmt.Errorf("the range must be positive")
+			}
+			holds, err := stack.ballistics.holds([]float64{top})
+			if err != nil {
+				return nil, err
+			}
+			return Floats{holds[0].elevation, holds[0].windage}, nil
+		},
+	}
+)
+
+func wrapStoreBallisticsOp(name, doc string, register func(*Ballistics) *float64) Op {
+	return Op{
+		fmt.Sprintf("store stack.Top() in the ballistic %s register, the %s", name, doc),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			*register(&stack.ballistics) = top
+			return nil, nil
+		},
+	}
+}
+
+func wrapDragModelOp(doc string, model DragModel) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			stack.ballistics.model = model
+			return nil, nil
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDragTables(t *testing.T) {
+	assert.InDelta(t, 0.2629, DragG1.cd(0), 1e-9)
+	assert.InDelta(t, 0.4805, DragG1.cd(1), 1e-9)
+	assert.InDelta(t, (0.3803+0.4015)/2, DragG7.cd(1.0125), 1e-9)
+	assert.InDelta(t, 0.1618, DragG7.cd(9), 1e-9)
+}
+
+func TestBallisticsVacuum(t *testing.T) {
+	// with next to no drag the trajectory is the vacuum parabola
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "3000", "mv", "1e12", "bc", "0", "sight", "100", "zero", "300", "hold")
+	elevation := stack.PopU()
+	windage := stack.PopU()
+
+	v := 3000.0
+	zero := 300.0
+	x := 900.0
+	angle := math.Asin(_gravityFtPerSec2*zero/(v*v)) / 2
+	cos := v * math.Cos(angle)
+	y := x*math.Tan(angle) - _gravityFtPerSec2*x*x/(2*cos*cos)
+	assert.InDelta(t, -1000*math.Atan(y/x), elevation, 1e-6)
+	assert.InDelta(t, 0, windage, 1e-9)
+}
+
+func TestBallisticsHolds(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "2600", "mv", "0.505", "bc", "100", "hold")
+	assert.InDelta(t, 0, stack.PopU(), 1e-6)
+	stack.PopU()
+
+	runAll(t, stack, ops, "1000", "hold")
+	mils := stack.PopU()
+	assert.InDelta(t, 11.4, mils, 0.2)
+	stack.PopU()
+
+	// the drop grows with range
+	runAll(t, stack, ops, "500", "hold")
+	assert.Less(t, stack.PopU(), mils)
+	stack.PopU()
+
+	// a left to right wind needs a hold to the left
+	runAll(t, stack, ops, "10", "wind", "moa", "1000", "hold")
+	assert.InDelta(t, mils*_moaPerRad/1000, stack.PopU(), 1e-9)
+	assert.Less(t, stack.PopU(), 0.0)
+
+	// thinner air at altitude drops less
+	runAll(t, stack, ops, "mils", "5000", "balt", "1000", "hold")
+	assert.Less(t, stack.PopU(), mils)
+	stack.PopU()
+
+	// a hot day is thinner air too
+	runAll(t, stack, ops, "0", "balt", "35", "btemp", "1000", "hold")
+	assert.Less(t, stack.PopU(), mils)
+	stack.PopU()
+
+	runAll(t, stack, ops, "g7", "0.243", "bc", "clbal")
+	assert.Equal(t, NewBallistics(), stack.ballistics)
+}
+
+func TestBallisticsErrors(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(100)
+	assert.NotNil(t, ops.Run("hold", stack))
+	assert.Equal(t, 100.0, stack.PopU())
+
+	runAll(t, stack, ops, "800", "mv", "0.1", "bc")
+	stack.Push(5000)
+	assert.NotNil(t, ops.Run("hold", stack))
+
+	stack.Push(1000)
+	stack.Push(0)
+	assert.NotNil(t, ops.Run("card", stack))
+}
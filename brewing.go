@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The brewing ops work in the units of American homebrewing: specific
+// gravity, degrees Plato and Brix, ounces, gallons and °F, with ibum taking
+// grams and liters. Gravity converts to Plato by the usual cubic fit, and
+// bitterness follows Tinseth.
+
+const (
+	_abvPerGravity        = 131.25
+	_wortCorrectionFactor = 1.04
+	_pasteurizationTemp   = 140
+	_primingGramsPerGal   = 15.195
+	_strikeFactor         = 0.2
+	_gPerOz               = _pPerKg * 1000 / 16
+)
+
+// pasteurization is the time in seconds to pasteurize at fahrenheit.
+func pasteurization(fahrenheit float64) float64 {
+	return math.Exp(fahrenheit*-0.231) * 1.23e15 * 60
+}
+
+// pasteurizationUnits converts a hold of minutes at fahrenheit to minutes at
+// 140 °F, the definition of the unit, scaling by the pas formula.
+func pasteurizationUnits(minutes, fahrenheit float64) float64 {
+	return minutes * pasteurization(_pasteurizationTemp) / pasteurization(fahrenheit)
+}
+
+func sgToPlato(sg float64) float64 {
+	return ((135.997*sg-630.272)*sg+1111.14)*sg - 616.868
+}
+
+func platoToSG(plato float64) float64 {
+	return 1 + plato/(258.6-plato/258.2*227.1)
+}
+
+// tinseth is the IBU of alphaMg mg/l of alpha acids boiled for minutes in
+// wort of gravity og.
+func tinseth(alphaMg, minutes, og float64) float64 {
+	bigness := 1.65 * math.Pow(0.000125, og-1)
+	boil := (1 - math.Exp(-0.04*minutes)) / 4.15
+	return bigness * boil * alphaMg
+}
+
+// residualCO2 is the volumes of CO2 left in beer that fermented at
+// fahrenheit.
+func residualCO2(fahrenheit float64) float64 {
+	return 3.0378 - 0.050062*fahrenheit + 0.00026555*fahrenheit*fahrenheit
+}
+
+// hydrometerDensity is the relative density of water at fahrenheit that a
+// hydrometer reading is corrected by.
+func hydrometerDensity(fahrenheit float64) float64 {
+	return ((-0.00000000232820948*fahrenheit+0.00000204052596)*fahrenheit-0.000134722124)*fahrenheit + 1.00130346
+}
+
+var (
+	sgToPlatoOp   = wrapUnaryOp("sg sg>plato, specific gravity to degrees Plato", sgToPlato)
+	platoToSGOp   = wrapUnaryOp("plato plato>sg, degrees Plato to specific gravity", platoToSG)
+	brixToPlatoOp = wrapUnaryOp("brix brix>plato, refractometer Brix to degrees Plato of wort", func(x float64) float64 { return x / _wortCorrectionFactor })
+	platoToBrixOp = wrapUnaryOp("plato plato>brix, degrees Plato of wort to refractometer Brix", func(x float64) float64 { return x * _wortCorrectionFactor })
+	sgToBrixOp    = wrapUnaryOp("sg sg>brix, specific gravity to refractometer Brix", func(x float64) float64 { return sgToPlato(x) * _wortCorrectionFactor })
+	brixToSGOp    = wrapUnaryOp("brix brix>sg, refractometer Brix to specific gravity", func(x float64) float64 { return platoToSG(x / _wortCorrectionFactor) })
+
+	pasteurizationUnitsOp = wrapBrewOp("minutes temp pu, pasteurization units for minutes held at temp °F", 2, func(x Floats) (float64, error) {
+		return pasteurizationUnits(x[0], x[1]), nil
+	})
+
+	abvOp = wrapBrewOp("og fg abv, alcohol by volume in percent from original and final gravity", 2, func(x Floats) (float64, error) {
+		return (x[0] - x[1]) * _abvPerGravity, nil
+	})
+
+	ibuOp = wrapBrewOp("aa oz minutes gallons og ibu, Tinseth IBU of oz of aa% hops boiled minutes in gallons of wort", 5, func(x Floats) (float64, error) {
+		if x[3] <= 0 {
+			return 0, fmt.Errorf("the wort volume must be positive")
+		}
+		return tinseth(x[0]/100*x[1]*_gPerOz*1000/(x[3]*_lPerGal), x[2], x[4]), nil
+	})
+
+	ibumOp = wrapBrewOp("aa grams minutes liters og ibum, Tinseth IBU of grams of aa% hops boiled minutes in liters of wort", 5, func(x Floats) (float64, error) {
+		if x[3] <= 0 {
+			return 0, fmt.Errorf("the wort volume must be positive")
+		}
+		return tinseth(x[0]/100*x[1]*1000/x[3], x[2], x[4]), nil
+	})
+
+	primingSugarOp = wrapBrewOp("gallons temp vols primesugar, grams of table sugar to carbonate gallons of beer that fermented at temp °F to vols of CO2", 3, func(x Floats) (float64, error) {
+		return _primingGramsPerGal * x[0] * (x[2] - residualCO2(x[1])), nil
+	})
+
+	hydrometerOp = wrapBrewOp("sg temp calibration hydro, hydrometer reading sg at temp °F corrected for a hydrometer calibrated at calibration °F", 3, func(x Floats) (float64, error) {
+		return x[0] * hydrometerDensity(x[1]) / hydrometerDensity(x[2]), nil
+	})
+
+	strikeOp = wrapBrewOp("ratio grain target strike, strike water °F for a mash of ratio quarts per pound with grain at grain °F to settle at target °F", 3, func(x Floats) (float64, error) {
+		if x[0] <= 0 {
+			return 0, fmt.Errorf("the water to grain ratio must be positive")
+		}
+		return _strikeFactor/x[0]*(x[2]-x[1]) + x[2], nil
+	})
+)
+
+// wrapBrewOp pops n values, bottom first, and pushes f of them.
+func wrapBrewOp(doc string, n int, f func(Floats) (float64, error)) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(n)
+			if err != nil {
+				return nil, err
+			}
+			result, err := f(elems)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGravityConversions(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1.040", "sg>plato")
+	assert.InDelta(t, 10, stack.PopU(), 0.05)
+
+	runAll(t, stack, ops, "12", "plato>sg", "sg>plato")
+	assert.InDelta(t, 12, stack.PopU(), 0.01)
+
+	runAll(t, stack, ops, "10.4", "brix>plato")
+	assert.InDelta(t, 10, stack.PopU(), 1e-9)
+
+	runAll(t, stack, ops, "10", "plato>brix")
+	assert.InDelta(t, 10.4, stack.PopU(), 1e-9)
+
+	runAll(t, stack, ops, "1.060", "sg>brix", "brix>sg")
+	assert.InDelta(t, 1.060, stack.PopU(), 1e-4)
+}
+
+func TestABV(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1.050", "1.010", "abv")
+	assert.InDelta(t, 5.25, stack.PopU(), 1e-9)
+}
+
+func TestTinseth(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "10", "1", "60", "5", "1.050", "ibu")
+	ibu := stack.PopU()
+	assert.InDelta(t, 34.56, ibu, 0.05)
+
+	runAll(t, stack, ops, "10", "28.349523125", "60", "18.92705892", "1.050", "ibum")
+	assert.InDelta(t, ibu, stack.PopU(), 1e-6)
+
+	// a thicker wort and a shorter boil use less of the hops
+	runAll(t, stack, ops, "10", "1", "60", "5", "1.080", "ibu")
+	assert.Less(t, stack.PopU(), ibu)
+	runAll(t, stack, ops, "10", "1", "15", "5", "1.050", "ibu")
+	assert.Less(t, stack.PopU(), ibu)
+
+	for _, x := range []float64{10, 1, 60, 0, 1.05} {
+		stack.Push(x)
+	}
+	assert.NotNil(t, ops.Run("ibu", stack))
+	assert.Equal(t, 5, stack.Len())
+}
+
+func TestPriming(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "5", "68", "2.5", "primesugar")
+	assert.InDelta(t, 124.5, stack.PopU(), 0.1)
+}
+
+func TestHydrometer(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1.050", "60", "60", "hydro")
+	assert.InDelta(t, 1.050, stack.PopU(), 1e-12)
+
+	runAll(t, stack, ops, "1.050", "80", "60", "hydro")
+	assert.InDelta(t, 1.0524, stack.PopU(), 1e-4)
+}
+
+func TestStrike(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1.5", "70", "152", "strike")
+	assert.InDelta(t, 152+0.2/1.5*82, stack.PopU(), 1e-9)
+}
+
+func TestPasteurizationUnits(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "10", "140", "pu")
+	assert.InDelta(t, 10, stack.PopU(), 1e-9)
+
+	runAll(t, stack, ops, "10", "145", "pu")
+	assert.InDelta(t, 10*math.Exp(0.231*5), stack.PopU(), 1e-9)
+
+	// holding for the pas time at any temperature gives the same units
+	runAll(t, stack, ops, "150", "pas", "60", "/", "150", "pu")
+	assert.InDelta(t, pasteurization(140)/60, stack.PopU(), 1e-9)
+}
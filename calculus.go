@@ -0,0 +1,472 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// The numerical ops work on a single function of x defined with a line such
+// as f(x) = x**2 - 2. The expr is compiled once and evaluated with x bound to
+// each sample point and s bound to the stack, exactly as tryExpr sees it,
+// alongside the usual math functions.
+
+const (
+	_brentIterations     = 200
+	_brentTolerance      = 1e-15
+	_kronrodTolerance    = 1e-10
+	_kronrodMaxIntervals = 1000
+	_riddersTableSize    = 10
+	_riddersStepShrink   = 1.4
+)
+
+var _defineRe = regexp.MustCompile(`^f\(x\)\s*=\s*(.+)$`)
+
+// _mathFunctions fills the gaps in expr's builtins, which already provide
+// abs, ceil, floor, round, min and max.
+var _mathFunctions = map[string]any{
+	"pi":    math.Pi,
+	"e":     math.E,
+	"sqrt":  math.Sqrt,
+	"cbrt":  math.Cbrt,
+	"exp":   math.Exp,
+	"log":   math.Log,
+	"log2":  math.Log2,
+	"log10": math.Log10,
+	"pow":   math.Pow,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"asin":  math.Asin,
+	"acos":  math.Acos,
+	"atan":  math.Atan,
+	"atan2": math.Atan2,
+	"sinh":  math.Sinh,
+	"cosh":  math.Cosh,
+	"tanh":  math.Tanh,
+	"hypot": math.Hypot,
+	"erf":   math.Erf,
+	"gamma": math.Gamma,
+}
+
+type Function struct {
+	source  string
+	program *vm.Program
+}
+
+func functionEnv(x float64, stack *Stack) map[string]any {
+	values := stack.Values()
+	slices.Reverse(values)
+	s := make([]any, 0, len(values))
+	for _, v := range values {
+		s = append(s, toAny(v))
+	}
+	env := map[string]any{
+		"x": x,
+		"s": s,
+	}
+	for name, f := range _mathFunctions {
+		env[name] = f
+	}
+	return env
+}
+
+func compileFunction(source string, stack *Stack) (*Function, error) {
+	program, err := expr.Compile(source, expr.Env(functionEnv(0, stack)), expr.AsFloat64())
+	if err != nil {
+		return nil, err
+	}
+	return &Function{source, program}, nil
+}
+
+// bind fixes s to the current stack and returns f as a plain function of x.
+func (f *Function) bind(stack *Stack) func(float64) (float64, error) {
+	env := functionEnv(0, stack)
+	return func(x float64) (float64, error) {
+		env["x"] = x
+		output, err := expr.Run(f.program, env)
+		if err != nil {
+			return 0, err
+		}
+		return output.(float64), nil
+	}
+}
+
+func tryDefine(line string, stack *Stack) error {
+	match := _defineRe.FindStringSubmatch(line)
+	if match == nil {
+		return fmt.Errorf("not a function definition")
+	}
+	f, err := compileFunction(strings.TrimSpace(match[1]), stack)
+	if err != nil {
+		return failed(err)
+	}
+	stack.function = f
+	return nil
+}
+
+func boundFunction(stack *Stack) (func(float64) (float64, error), error) {
+	if stack.function == nil {
+		return nil, fmt.Errorf("no function defined, try f(x) = x**2 - 2")
+	}
+	return stack.function.bind(stack), nil
+}
+
+// brent finds a root of f bracketed by a and b using Brent's method.
+func brent(f func(float64) (float64, error), a, b float64) (float64, error) {
+	fa, err := f(a)
+	if err != nil {
+		return 0, err
+	}
+	fb, err := f(b)
+	if err != nil {
+		return 0, err
+	}
+	if fa == 0 {
+		return a, nil
+	}
+	if fb == 0 {
+		return b, nil
+	}
+	if math.Signbit(fa) == math.Signbit(fb) {
+		return 0, fmt.Errorf("f(%g) and f(%g) have the same sign, the root is not bracketed", a, b)
+	}
+	c, fc := b, fb
+	var d, e float64
+	for range _brentIterations {
+		if math.Signbit(fb) == math.Signbit(fc) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		tol := 2*_brentTolerance*math.Abs(b) + _brentTolerance/2
+		m := (c - b) / 2
+		if math.Abs(m) <= tol || fb == 0 {
+			return b, nil
+		}
+		if math.Abs(e) >= tol && math.Abs(fa) > math.Abs(fb) {
+			// inverse quadratic interpolation, or secant when a == c
+			var p, q float64
+			s := fb / fa
+			if a == c {
+				p = 2 * m * s
+				q = 1 - s
+			} else {
+				q = fa / fc
+				r := fb / fc
+				p = s * (2*m*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			} else {
+				p = -p
+			}
+			if 2*p < min(3*m*q-math.Abs(tol*q), math.Abs(e*q)) {
+				e = d
+				d = p / q
+			} else {
+				d = m
+				e = d
+			}
+		} else {
+			d = m
+			e = d
+		}
+		a, fa = b, fb
+		if math.Abs(d) > tol {
+			b += d
+		} else {
+			b += math.Copysign(tol, m)
+		}
+		fb, err = f(b)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return 0, fmt.Errorf("no convergence after %d iterations", _brentIterations)
+}
+
+var (
+	_kronrodNodes = [8]float64{
+		0.991455371120812639206854697526329,
+		0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926,
+		0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013,
+		0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245,
+		0,
+	}
+	_kronrodWeights = [8]float64{
+		0.022935322010529224963732008058970,
+		0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518,
+		0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550,
+		0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649,
+		0.209482141084727828012999174891714,
+	}
+	_gaussWeights = [4]float64{
+		0.129484966168869693270611432679082,
+		0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975,
+		0.417959183673469387755102040816327,
+	}
+)
+
+// gaussKronrod applies the 15 point Kronrod rule over [a, b] and returns the
+// estimate with the difference from the embedded 7 point Gauss rule.
+func gaussKronrod(f func(float64) (float64, error), a, b float64) (float64, float64, error) {
+	center := (a + b) / 2
+	half := (b - a) / 2
+	kronrod, gauss := 0.0, 0.0
+	for i, node := range _kronrodNodes {
+		var y float64
+		if node == 0 {
+			fc, err := f(center)
+			if err != nil {
+				return 0, 0, err
+			}
+			y = fc
+		} else {
+			f1, err := f(center - half*node)
+			if err != nil {
+				return 0, 0, err
+			}
+			f2, err := f(center + half*node)
+			if err != nil {
+				return 0, 0, err
+			}
+			y = f1 + f2
+		}
+		kronrod += _kronrodWeights[i] * y
+		if i%2 == 1 {
+			gauss += _gaussWeights[i/2] * y
+		}
+	}
+	return kronrod * half, math.Abs((kronrod - gauss) * half), nil
+}
+
+// kronrodInterval is a piece of the integration range with its estimate and
+// error estimate.
+type kronrodInterval struct {
+	a        float64
+	b        float64
+	estimate float64
+	error    float64
+}
+
+// integrate bisects the interval with the largest error estimate until the
+// total error meets the tolerance. It gives up with an error after
+// _kronrodMaxIntervals pieces, or when a piece can no longer be split, as
+// happens for divergent and wildly oscillating integrands.
+func integrate(f func(float64) (float64, error), a, b float64) (float64, error) {
+	if math.IsInf(a, 0) || math.IsInf(b, 0) || math.IsNaN(a) || math.IsNaN(b) {
+		return 0, fmt.Errorf("integration bounds must be finite")
+	}
+	piece := func(a, b float64) (kronrodInterval, error) {
+		estimate, errorEstimate, err := gaussKronrod(f, a, b)
+		return kronrodInterval{a, b, estimate, errorEstimate}, err
+	}
+	first, err := piece(a, b)
+	if err != nil {
+		return 0, err
+	}
+	intervals := []kronrodInterval{first}
+	for {
+		total, totalError, worst := 0.0, 0.0, 0
+		for k, interval := range intervals {
+			total += interval.estimate
+			totalError += interval.error
+			if interval.error > intervals[worst].error {
+				worst = k
+			}
+		}
+		if math.IsNaN(total) || math.IsInf(total, 0) {
+			return 0, fmt.Errorf("the integrand is not finite over [%g, %g]", a, b)
+		}
+		if totalError <= _kronrodTolerance*max(1, math.Abs(total)) {
+			return total, nil
+		}
+		split := intervals[worst]
+		mid := (split.a + split.b) / 2
+		if len(intervals) >= _kronrodMaxIntervals || mid == split.a || mid == split.b {
+			return 0, fmt.Errorf("integral did not converge, estimate %g with error %g", total, totalError)
+		}
+		left, err := piece(split.a, mid)
+		if err != nil {
+			return 0, err
+		}
+		right, err := piece(mid, split.b)
+		if err != nil {
+			return 0, err
+		}
+		intervals[worst] = left
+		intervals = append(intervals, right)
+	}
+}
+
+// derivative uses Ridders' extrapolation of central differences.
+func derivative(f func(float64) (float64, error), x float64) (float64, error) {
+	h := 0.1 * max(1, math.Abs(x))
+	difference := func(h float64) (float64, error) {
+		above, err := f(x + h)
+		if err != nil {
+			return 0, err
+		}
+		below, err := f(x - h)
+		if err != nil {
+			return 0, err
+		}
+		return (above - below) / (2 * h), nil
+	}
+	var table [_riddersTableSize][_riddersTableSize]float64
+	first, err := difference(h)
+	if err != nil {
+		return 0, err
+	}
+	table[0][0] = first
+	result := first
+	best := math.Inf(1)
+	const shrinkSq = _riddersStepShrink * _riddersStepShrink
+	for i := 1; i < _riddersTableSize; i++ {
+		h /= _riddersStepShrink
+		estimate, err := difference(h)
+		if err != nil {
+			return 0, err
+		}
+		table[0][i] = estimate
+		factor := shrinkSq
+		for j := 1; j <= i; j++ {
+			table[j][i] = (table[j-1][i]*factor - table[j-1][i-1]) / (factor - 1)
+			factor *= shrinkSq
+			errorEstimate := max(math.Abs(table[j][i]-table[j-1][i]), math.Abs(table[j][i]-table[j-1][i-1]))
+			if errorEstimate <= best {
+				best = errorEstimate
+				result = table[j][i]
+			}
+		}
+		if math.Abs(table[i][i]-table[i-1][i-1]) >= 2*best {
+			break
+		}
+	}
+	return result, nil
+}
+
+var (
+	showFunctionOp = Op{
+		"print the function defined with f(x) = ...",
+		func(stack *Stack) (Floats, error) {
+			if stack.function == nil {
+				return nil, fmt.Errorf("no function defined, try f(x) = x**2 - 2")
+			}
+			fmt.Printf("f(x) = %s\n", stack.function.source)
+			return nil, nil
+		},
+	}
+
+	fxOp = Op{
+		"evaluate f(x) at stack.Top()",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.TopValue()
+			if err != nil {
+				return nil, err
+			}
+			f, err := boundFunction(stack)
+			if err != nil {
+				return nil, err
+			}
+			var evalErr error
+			result, err := mapValue(top, func(x float64) float64 {
+				y, err := f(x)
+				if err != nil {
+					evalErr = err
+				}
+				return y
+			})
+			if err != nil {
+				return nil, err
+			}
+			if evalErr != nil {
+				return nil, evalErr
+			}
+			_, _ = stack.PopValue()
+			return pushResult(stack, result)
+		},
+	}
+
+	integrateOp = Op{
+		"a b integrate, integral of f(x) from a to b",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			f, err := boundFunction(stack)
+			if err != nil {
+				return nil, err
+			}
+			result, err := integrate(f, elems[0], elems[1])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	derivOp = Op{
+		"x deriv, derivative of f(x) at x",
+		func(stack *Stack) (Floats, error) {
+			x, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			f, err := boundFunction(stack)
+			if err != nil {
+				return nil, err
+			}
+			result, err := derivative(f, x)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	// solve works on a linear system when given a matrix and a vector and on
+	// f(x) when given two scalar bounds.
+	solveOp = Op{
+		"A b solve, the vector x with Ax = b; a b solve, the root of f(x) between a and b",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopValues(2)
+			if err != nil {
+				return nil, err
+			}
+			a, aScalar := scalarOf(elems[0])
+			b, bScalar := scalarOf(elems[1])
+			if !aScalar || !bScalar {
+				return solveSystem(stack, elems)
+			}
+			f, err := boundFunction(stack)
+			if err != nil {
+				return nil, err
+			}
+			result, err := brent(f, a, b)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+)
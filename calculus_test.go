@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func defineFunction(t *testing.T, stack *Stack, line string) {
+	t.Helper()
+	assert.Nil(t, tryDefine(line, stack))
+	if assert.NotNil(t, stack.function) {
+		assert.Equal(t, _defineRe.FindStringSubmatch(line)[1], stack.function.source)
+	}
+}
+
+func TestDefine(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.NotNil(t, tryDefine("x**2", stack))
+	assert.NotNil(t, ops.Run("fx", stack))
+
+	err := cascade("f(x) = x +* 2", stack, ops)
+	assert.True(t, isFailed(err))
+	assert.Nil(t, stack.function)
+	assert.Empty(t, stack.history.snapshots)
+
+	defineFunction(t, stack, "f(x) = x**2 - 2")
+	stack.Push(3)
+	assert.Nil(t, ops.Run("fx", stack))
+	assert.Equal(t, 7.0, stack.PopU())
+
+	pushLiteral(t, stack, "[0 1 2]")
+	assert.Nil(t, ops.Run("fx", stack))
+	assert.Equal(t, "[ [-2 -1 2] ]", stack.String())
+}
+
+func TestDefineUsesStack(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	defineFunction(t, stack, "f(x) = x - s[0]")
+	stack.Push(5)
+	stack.Push(0)
+	stack.Push(10)
+	assert.Nil(t, ops.Run("solve", stack))
+	assertClose(t, 5, stack.PopU())
+}
+
+func TestSolveRoot(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	defineFunction(t, stack, "f(x) = x**2 - 2")
+	pushAll(stack, 0, 2)
+	assert.Nil(t, ops.Run("solve", stack))
+	assertClose(t, math.Sqrt2, stack.PopU())
+
+	defineFunction(t, stack, "f(x) = cos(x) - x")
+	pushAll(stack, 0, 1)
+	assert.Nil(t, ops.Run("solve", stack))
+	assert.InDelta(t, 0.7390851332151607, stack.PopU(), 1e-14)
+
+	pushAll(stack, 2, 3)
+	assert.NotNil(t, ops.Run("solve", stack))
+}
+
+func TestIntegrate(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	defineFunction(t, stack, "f(x) = x**2")
+	pushAll(stack, 0, 3)
+	assert.Nil(t, ops.Run("integrate", stack))
+	assertClose(t, 9, stack.PopU())
+
+	defineFunction(t, stack, "f(x) = sin(x)")
+	pushAll(stack, 0, math.Pi)
+	assert.Nil(t, ops.Run("integrate", stack))
+	assert.InDelta(t, 2, stack.PopU(), 1e-12)
+
+	defineFunction(t, stack, "f(x) = sqrt(x)")
+	pushAll(stack, 0, 1)
+	assert.Nil(t, ops.Run("integrate", stack))
+	assert.InDelta(t, 2.0/3.0, stack.PopU(), 1e-9)
+
+	defineFunction(t, stack, "f(x) = exp(-x*x)")
+	pushAll(stack, 2, -2)
+	assert.Nil(t, ops.Run("integrate", stack))
+	assert.InDelta(t, -math.Sqrt(math.Pi)*math.Erf(2), stack.PopU(), 1e-12)
+
+	defineFunction(t, stack, "f(x) = sin(1/x)")
+	pushAll(stack, 0, 1)
+	start := time.Now()
+	assert.NotNil(t, ops.Run("integrate", stack))
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	defineFunction(t, stack, "f(x) = 1/x")
+	pushAll(stack, -1e-9, 1)
+	assert.NotNil(t, ops.Run("integrate", stack))
+}
+
+func TestDeriv(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	defineFunction(t, stack, "f(x) = x**3")
+	stack.Push(2)
+	assert.Nil(t, ops.Run("deriv", stack))
+	assert.InDelta(t, 12, stack.PopU(), 1e-9)
+
+	defineFunction(t, stack, "f(x) = exp(x)")
+	stack.Push(1)
+	assert.Nil(t, ops.Run("deriv", stack))
+	assert.InDelta(t, math.E, stack.PopU(), 1e-9)
+}
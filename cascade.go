@@ -1,19 +1,134 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
+// failedError marks a line that a try function recognized but could not
+// carry out, so that cascade reports it instead of trying the line as
+// something else.
+type failedError struct {
+	err error
+}
+
+func (e failedError) Error() string {
+	return e.err.Error()
+}
+
+func (e failedError) Unwrap() error {
+	return e.err
+}
+
+func failed(err error) error {
+	return failedError{err}
+}
+
+func isFailed(err error) bool {
+	var f failedError
+	return errors.As(err, &f)
+}
+
+// cascade handles one line of input. Values and definitions are tried before
+// operations, and only operations are remembered for the empty line repeat.
+// A line that is recognized but fails leaves the stack as it was and returns
+// its error, and a line that is not recognized at all prints the help.
 func cascade(line string, stack *Stack, ops *Ops) error {
-	err := tryExpr(line, stack)
+	if len(line) <= 0 {
+		if stack.repeat.disabled || len(stack.repeat.lastOp) <= 0 {
+			return nil
+		}
+		line = stack.repeat.lastOp
+	}
+
+	before := stack.Snapshot()
+	if line != "undo" {
+		defer func() {
+			stack.history.Record(before, stack.Snapshot())
+		}()
+	}
+
+	err := tryDecimal(line, stack)
 	if err == nil {
 		return nil
 	}
 
-	err = ops.Run(line, stack)
+	err = tryUncertain(line, stack)
+	if err == nil {
+		return nil
+	}
+
+	err = tryTime(line, stack)
+	if err == nil {
+		return nil
+	}
+
+	err = tryExpr(line, stack)
+	if err == nil {
+		return nil
+	}
+
+	err = tryArray(line, stack)
+	if err == nil {
+		return nil
+	}
+
+	err = tryDefine(line, stack)
 	if err == nil {
 		return nil
 	}
+	if isFailed(err) {
+		stack.Restore(before)
+		return err
+	}
 
-	fmt.Println(ops.Help())
+	err = tryDice(line, stack)
+	if err == nil {
+		return nil
+	}
 
+	err = tryRepeat(line, stack, ops)
+	if err == nil {
+		stack.repeat.lastOp = line
+		return nil
+	}
+	if isFailed(err) {
+		stack.Restore(before)
+		return err
+	}
+
+	err = tryZone(line, stack)
+	if err == nil {
+		return nil
+	}
+	if isFailed(err) {
+		stack.Restore(before)
+		return err
+	}
+
+	err = tryConst(line, stack)
+	if err == nil {
+		return nil
+	}
+
+	err = tryCombinator(line, stack, ops)
+	if err == nil {
+		stack.repeat.lastOp = line
+		return nil
+	}
+	if isFailed(err) {
+		stack.Restore(before)
+		return err
+	}
+
+	if _, ok := ops.opmap[line]; !ok {
+		fmt.Println(ops.Help())
+		return nil
+	}
+	err = ops.Run(line, stack)
+	if err != nil {
+		return err
+	}
+	stack.repeat.lastOp = line
 	return nil
 }
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The physical constants are the CODATA 2018 recommended values in SI units.
+// The constants that define the SI, and those derived from them alone, are
+// exact and have no uncertainty. const <symbol> pushes one, const <text>
+// lists those whose symbol or name contains text, and help const lists all.
+
+type Constant struct {
+	symbol      string
+	name        string
+	value       float64
+	uncertainty float64
+	unit        string
+}
+
+var _constants = []Constant{
+	{"c", "speed of light in vacuum", _c, 0, "m/s"},
+	{"h", "Planck constant", 6.62607015e-34, 0, "J/Hz"},
+	{"hbar", "reduced Planck constant", 1.054571817e-34, 0, "J s"},
+	{"e", "elementary charge", 1.602176634e-19, 0, "C"},
+	{"kB", "Boltzmann constant", 1.380649e-23, 0, "J/K"},
+	{"NA", "Avogadro constant", 6.02214076e23, 0, "1/mol"},
+	{"R", "molar gas constant", 8.314462618, 0, "J/(mol K)"},
+	{"F", "Faraday constant", 96485.33212, 0, "C/mol"},
+	{"sigma", "Stefan-Boltzmann constant", 5.670374419e-8, 0, "W/(m² K⁴)"},
+	{"b", "Wien wavelength displacement law constant", 2.897771955e-3, 0, "m K"},
+	{"eV", "electron volt", 1.602176634e-19, 0, "J"},
+	{"Vm", "molar volume of ideal gas at 273.15 K and 101.325 kPa", 22.41396954e-3, 0, "m³/mol"},
+	{"G", "Newtonian constant of gravitation", 6.67430e-11, 0.00015e-11, "m³/(kg s²)"},
+	{"gn", "standard acceleration of gravity", 9.80665, 0, "m/s²"},
+	{"atm", "standard atmosphere", 101325, 0, "Pa"},
+	{"eps0", "vacuum electric permittivity", 8.8541878128e-12, 0.0000000013e-12, "F/m"},
+	{"mu0", "vacuum magnetic permeability", 1.25663706212e-6, 0.00000000019e-6, "N/A²"},
+	{"Z0", "characteristic impedance of vacuum", 376.730313668, 0.000000057, "Ω"},
+	{"alpha", "fine-structure constant", 7.2973525693e-3, 0.0000000011e-3, ""},
+	{"Rinf", "Rydberg constant", 10973731.568160, 0.000021, "1/m"},
+	{"a0", "Bohr radius", 5.29177210903e-11, 0.00000000080e-11, "m"},
+	{"re", "classical electron radius", 2.8179403262e-15, 0.0000000013e-15, "m"},
+	{"me", "electron mass", 9.1093837015e-31, 0.0000000028e-31, "kg"},
+	{"mp", "proton mass", 1.67262192369e-27, 0.00000000051e-27, "kg"},
+	{"mn", "neutron mass", 1.67492749804e-27, 0.00000000095e-27, "kg"},
+	{"u", "atomic mass constant", 1.66053906660e-27, 0.00000000050e-27, "kg"},
+	{"muB", "Bohr magneton", 9.2740100783e-24, 0.0000000028e-24, "J/T"},
+	{"muN", "nuclear magneton", 5.0507837461e-27, 0.0000000015e-27, "J/T"},
+}
+
+// The inexact constants all follow from a few measured quantities, so in
+// uconst mode each is pushed with its error split among those sources by the
+// power it depends on them to. ε0, μ0, Z0, a0 and re all go with α, and the
+// particle masses with R∞, α and their mass ratios, so their uncertainties
+// cancel as they should, as in const eps0 const mu0 * const c dup * *.
+const (
+	_sourceAlpha = iota
+	_sourceRydberg
+	_sourceElectronMass
+	_sourceProtonRatio
+	_sourceNeutronRatio
+	_sourceGravitation
+)
+
+// _sourceUncertainties are the relative standard uncertainties of α, R∞,
+// the relative atomic mass of the electron, mp/me, mn/me and G.
+var _sourceUncertainties = []float64{
+	_sourceAlpha:        0.0000000011e-3 / 7.2973525693e-3,
+	_sourceRydberg:      0.000021 / 10973731.568160,
+	_sourceElectronMass: 0.00000000016e-4 / 5.48579909065e-4,
+	_sourceProtonRatio:  0.00000011 / 1836.15267343,
+	_sourceNeutronRatio: 0.00000089 / 1838.68366173,
+	_sourceGravitation:  0.00015e-11 / 6.67430e-11,
+}
+
+// _constantPowers gives each inexact constant as a product of powers of the
+// sources, leaving out the exact factors, so me = 2hR∞/(α²c) is R∞ α⁻².
+var _constantPowers = map[string]map[int]float64{
+	"G":     {_sourceGravitation: 1},
+	"eps0":  {_sourceAlpha: -1},
+	"mu0":   {_sourceAlpha: 1},
+	"Z0":    {_sourceAlpha: 1},
+	"alpha": {_sourceAlpha: 1},
+	"Rinf":  {_sourceRydberg: 1},
+	"a0":    {_sourceAlpha: 1, _sourceRydberg: -1},
+	"re":    {_sourceAlpha: 3, _sourceRydberg: -1},
+	"me":    {_sourceAlpha: -2, _sourceRydberg: 1},
+	"mp":    {_sourceAlpha: -2, _sourceRydberg: 1, _sourceProtonRatio: 1},
+	"mn":    {_sourceAlpha: -2, _sourceRydberg: 1, _sourceNeutronRatio: 1},
+	"u":     {_sourceAlpha: -2, _sourceRydberg: 1, _sourceElectronMass: -1},
+	"muB":   {_sourceAlpha: 2, _sourceRydberg: -1},
+	"muN":   {_sourceAlpha: 2, _sourceRydberg: -1, _sourceProtonRatio: -1},
+}
+
+// _constantAliases maps the usual typeset and subscripted spellings of a
+// symbol to the ASCII one in _constants, and g to standard gravity.
+var _constantAliases = map[string]string{
+	"g":   "gn",
+	"ħ":   "hbar",
+	"k_B": "kB",
+	"N_A": "NA",
+	"σ":   "sigma",
+	"V_m": "Vm",
+	"g_n": "gn",
+	"ε0":  "eps0",
+	"ε₀":  "eps0",
+	"μ0":  "mu0",
+	"µ0":  "mu0",
+	"μ₀":  "mu0",
+	"Z₀":  "Z0",
+	"α":   "alpha",
+	"R∞":  "Rinf",
+	"a₀":  "a0",
+	"r_e": "re",
+	"m_e": "me",
+	"m_p": "mp",
+	"m_n": "mn",
+	"μB":  "muB",
+	"μ_B": "muB",
+	"μN":  "muN",
+	"μ_N": "muN",
+}
+
+func (c Constant) String() string {
+	uncertainty := "exact"
+	if c.uncertainty != 0 {
+		uncertainty = fmt.Sprintf("± %g", c.uncertainty)
+	}
+	return fmt.Sprintf("%-6s %-18g %-11s %-14s %s", c.symbol, c.value, c.unit, uncertainty, c.name)
+}
+
+// findConstant looks a symbol or one of its aliases up exactly, then
+// ignoring case if that leaves only one candidate. Single letters are only
+// matched exactly, since G and g, or R and r, mean different things.
+func findConstant(symbol string) (Constant, bool) {
+	if alias, ok := _constantAliases[symbol]; ok {
+		symbol = alias
+	}
+	var folded []Constant
+	for _, c := range _constants {
+		if c.symbol == symbol {
+			return c, true
+		}
+		if len(symbol) > 1 && strings.EqualFold(c.symbol, symbol) {
+			folded = append(folded, c)
+		}
+	}
+	if len(folded) == 1 {
+		return folded[0], true
+	}
+	return Constant{}, false
+}
+
+// searchConstants returns the constants whose symbol or name contains text,
+// ignoring case.
+func searchConstants(text string) []Constant {
+	text = strings.ToLower(text)
+	var found []Constant
+	for _, c := range _constants {
+		if strings.Contains(strings.ToLower(c.symbol), text) || strings.Contains(strings.ToLower(c.name), text) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+func printConstants(constants []Constant) {
+	for _, c := range constants {
+		fmt.Println(c)
+	}
+}
+
+// constantTerms splits the uncertainty of c among the sources it depends on.
+// Sources have ids of their own below zero, shared by every push.
+func constantTerms(c Constant) map[int64]float64 {
+	terms := map[int64]float64{}
+	for source, power := range _constantPowers[c.symbol] {
+		terms[-int64(source)-1] = c.value * power * _sourceUncertainties[source]
+	}
+	return terms
+}
+
+// pushConstant pushes c with its uncertainty in uconst mode.
+func pushConstant(stack *Stack, c Constant) {
+	if !stack.uncertain.constants || c.uncertainty == 0 {
+		stack.Push(c.value)
+		return
+	}
+	stack.PushValue(Uncertain{c.value, constantTerms(c)})
+}
+
+// tryConst handles help const and const <symbol or text>.
+func tryConst(line string, stack *Stack) error {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || (fields[0] != "const" && strings.Join(fields, " ") != "help const") {
+		return fmt.Errorf("not a constant command")
+	}
+	if fields[0] == "help" {
+		printConstants(_constants)
+		return nil
+	}
+	text := strings.Join(fields[1:], " ")
+	c, ok := findConstant(text)
+	if ok {
+		pushConstant(stack, c)
+		return nil
+	}
+	found := searchConstants(text)
+	if len(found) == 0 {
+		fmt.Printf("no constant matches '%s'\n", text)
+		return nil
+	}
+	printConstants(found)
+	return nil
+}
+
+var (
+	constOp = Op{
+		"const <symbol> pushes a CODATA constant, const <text> searches them and help const lists them",
+		func(stack *Stack) (Floats, error) {
+			printConstants(_constants)
+			return nil, nil
+		},
+	}
+)
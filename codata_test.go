@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConstant(t *testing.T) {
+	c, ok := findConstant("hbar")
+	assert.True(t, ok)
+	assert.InEpsilon(t, 6.62607015e-34/(2*math.Pi), c.value, 1e-9)
+
+	// exact case wins, then a unique case-insensitive match
+	c, ok = findConstant("kb")
+	assert.True(t, ok)
+	assert.Equal(t, "kB", c.symbol)
+
+	c, ok = findConstant("G")
+	assert.True(t, ok)
+	assert.Equal(t, 0.00015e-11, c.uncertainty)
+
+	_, ok = findConstant("planck")
+	assert.False(t, ok)
+
+	for alias, symbol := range map[string]string{"ħ": "hbar", "k_B": "kB", "N_A": "NA", "ε0": "eps0", "μ0": "mu0"} {
+		c, ok = findConstant(alias)
+		assert.True(t, ok, alias)
+		assert.Equal(t, symbol, c.symbol, alias)
+	}
+	for alias, symbol := range _constantAliases {
+		c, ok = findConstant(alias)
+		assert.True(t, ok, alias)
+		assert.Equal(t, symbol, c.symbol, alias)
+	}
+
+	// g is standard gravity, and single letters are matched exactly
+	c, ok = findConstant("g")
+	assert.True(t, ok)
+	assert.Equal(t, 9.80665, c.value)
+	_, ok = findConstant("C")
+	assert.False(t, ok)
+}
+
+func TestSearchConstants(t *testing.T) {
+	found := searchConstants("Planck")
+	assert.Equal(t, 2, len(found))
+	assert.Equal(t, "h", found[0].symbol)
+	assert.Equal(t, "hbar", found[1].symbol)
+
+	assert.Empty(t, searchConstants("phlogiston"))
+}
+
+func TestConstantsAreConsistent(t *testing.T) {
+	value := func(symbol string) float64 {
+		c, ok := findConstant(symbol)
+		assert.True(t, ok, symbol)
+		return c.value
+	}
+	assertClose(t, 1, value("NA")*value("kB")/value("R"))
+	assertClose(t, 1, value("NA")*value("e")/value("F"))
+	assertClose(t, 1, value("eps0")*value("mu0")*value("c")*value("c"))
+	assert.InEpsilon(t, value("mu0")*value("c"), value("Z0"), 1e-9)
+	assert.InEpsilon(t, value("e")*value("e")/(2*value("eps0")*value("h")*value("c")), value("alpha"), 1e-9)
+
+	// the sources account for each published uncertainty
+	for _, c := range _constants {
+		u := Uncertain{c.value, constantTerms(c)}
+		if c.uncertainty == 0 {
+			assert.Empty(t, u.terms, c.symbol)
+			continue
+		}
+		assert.InEpsilon(t, c.uncertainty, u.Sigma(), 0.05, c.symbol)
+	}
+
+	symbols := map[string]bool{}
+	for _, c := range _constants {
+		assert.False(t, symbols[c.symbol], c.symbol)
+		symbols[c.symbol] = true
+	}
+}
+
+func TestTryConst(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "const me", "const c", "/")
+	assert.InEpsilon(t, 9.1093837015e-31/299792458, stack.PopU(), 1e-12)
+
+	// a search only prints
+	runAll(t, stack, ops, "const mass", "help const", "const phlogiston")
+	assert.Equal(t, 0, stack.Len())
+
+	assert.NotNil(t, tryConst("constant", stack))
+	assert.NotNil(t, tryConst("help", stack))
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Combinators take the name of another op on the same line, as in map sqrt
+// and fold +. The sequence generators also accept their arguments inline, so
+// range 1 10 2 generates a sequence while a bare range is still the spread of
+// the stack.
+
+const (
+	_maxSequence = 1000000
+)
+
+var _inlineGenerators = map[string]string{
+	"iota":     "iota",
+	"range":    "arange",
+	"arange":   "arange",
+	"linspace": "linspace",
+}
+
+func tryCombinator(line string, stack *Stack, ops *Ops) error {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return fmt.Errorf("not a combinator")
+	}
+	switch fields[0] {
+	case "map", "fold":
+		if len(fields) != 2 {
+			return failed(fmt.Errorf("usage: %s <op>", fields[0]))
+		}
+		op, ok := ops.opmap[fields[1]]
+		if !ok {
+			return failed(fmt.Errorf("no operator '%s'", fields[1]))
+		}
+		combine := foldStack
+		if fields[0] == "map" {
+			combine = mapStack
+		}
+		err := combine(stack, op)
+		if err != nil {
+			return failed(err)
+		}
+		return nil
+	}
+	name, ok := _inlineGenerators[fields[0]]
+	if !ok {
+		return fmt.Errorf("not a combinator")
+	}
+	args := make([]float64, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return err
+		}
+		args = append(args, n)
+	}
+	saved := stack.Snapshot()
+	for _, n := range args {
+		stack.Push(n)
+	}
+	err := ops.Run(name, stack)
+	if err != nil {
+		stack.Restore(saved)
+		return failed(err)
+	}
+	return nil
+}
+
+// applyOp runs op against a scratch stack holding only operands, sharing
+// every other setting with stack, and returns the single value it leaves.
+// Registers the op changes, as Σ+ does in fold Σ+, are copied back.
+func applyOp(stack *Stack, op Op, operands ...Value) (Value, error) {
+	scratch := *stack
+	scratch.storage = append([]Value{}, operands...)
+	results, err := op.f(&scratch)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(results) - 1; i >= 0; i-- {
+		scratch.Push(results[i])
+	}
+	if len(scratch.storage) != 1 {
+		return nil, fmt.Errorf("op must turn %d values into 1 but left %d", len(operands), len(scratch.storage))
+	}
+	stack.Restore(Snapshot{stack.storage, scratch.Snapshot().registers})
+	return scratch.storage[0], nil
+}
+
+func mapStack(stack *Stack, op Op) error {
+	values := stack.Values()
+	for i, v := range values {
+		result, err := applyOp(stack, op, v)
+		if err != nil {
+			return err
+		}
+		values[i] = result
+	}
+	stack.storage = values
+	return nil
+}
+
+func foldStack(stack *Stack, op Op) error {
+	values := stack.Values()
+	if len(values) < 1 {
+		return fmt.Errorf("insufficient stack")
+	}
+	result := values[0]
+	for _, v := range values[1:] {
+		var err error
+		result, err = applyOp(stack, op, result, v)
+		if err != nil {
+			return err
+		}
+	}
+	stack.storage = []Value{result}
+	return nil
+}
+
+func checkSequenceLength(n float64) (int, error) {
+	if n < 0 || n != math.Trunc(n) || n > _maxSequence {
+		return 0, fmt.Errorf("sequence length must be an integer between 0 and %d", _maxSequence)
+	}
+	return int(n), nil
+}
+
+// arange steps from a towards b, including b when a step lands on it.
+func arange(a, b, step float64) (Floats, error) {
+	if step == 0 || math.IsNaN(step) || math.Signbit(b-a) != math.Signbit(step) && a != b {
+		return nil, fmt.Errorf("step %g never reaches %g from %g", step, b, a)
+	}
+	count, err := checkSequenceLength(math.Floor((b-a)/step*(1+1e-12)) + 1)
+	if err != nil {
+		return nil, err
+	}
+	result := make(Floats, count)
+	for i := range count {
+		result[i] = a + float64(i)*step
+	}
+	return result, nil
+}
+
+func linspace(a, b float64, n int) Floats {
+	result := make(Floats, n)
+	for i := range n {
+		switch i {
+		case 0:
+			result[i] = a
+		case n - 1:
+			result[i] = b
+		default:
+			result[i] = a + (b-a)*float64(i)/float64(n-1)
+		}
+	}
+	return result
+}
+
+// pushSequence leaves the first element deepest and the last on top.
+func pushSequence(seq Floats) (Floats, error) {
+	result := make(Floats, len(seq))
+	for i, x := range seq {
+		result[len(seq)-1-i] = x
+	}
+	return result, nil
+}
+
+var (
+	mapOp = Op{
+		"map <op>, apply a unary op to every stack element",
+		func(stack *Stack) (Floats, error) {
+			return nil, fmt.Errorf("usage: map <op>")
+		},
+	}
+
+	foldOp = Op{
+		"fold <op>, reduce the stack from the bottom up with a binary op",
+		func(stack *Stack) (Floats, error) {
+			return nil, fmt.Errorf("usage: fold <op>")
+		},
+	}
+
+	zipOp = Op{
+		"interleave the lower and upper halves of the stack, a1 a2 b1 b2 becomes a1 b1 a2 b2",
+		func(stack *Stack) (Floats, error) {
+			values := stack.Values()
+			if len(values)%2 != 0 {
+				return nil, fmt.Errorf("zip needs an even number of values but found %d", len(values))
+			}
+			half := len(values) / 2
+			zipped := make([]Value, 0, len(values))
+			for i := range half {
+				zipped = append(zipped, values[i], values[half+i])
+			}
+			stack.storage = zipped
+			return nil, nil
+		},
+	}
+
+	iotaOp = Op{
+		"n iota, push 1 through n",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			n, err := checkSequenceLength(top)
+			if err != nil {
+				return nil, err
+			}
+			seq := make(Floats, n)
+			for i := range seq {
+				seq[i] = float64(i + 1)
+			}
+			return pushSequence(seq)
+		},
+	}
+
+	arangeOp = Op{
+		"a b step arange, push a, a+step, ... up to b; range a b step inline",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			seq, err := arange(elems[0], elems[1], elems[2])
+			if err != nil {
+				return nil, err
+			}
+			return pushSequence(seq)
+		},
+	}
+
+	linspaceOp = Op{
+		"a b n linspace, push n evenly spaced values from a to b inclusive",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			n, err := checkSequenceLength(elems[2])
+			if err != nil {
+				return nil, err
+			}
+			return pushSequence(linspace(elems[0], elems[1], n))
+		},
+	}
+)
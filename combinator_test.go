@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 4, 9)
+	assert.Nil(t, tryCombinator("map sqrt", stack, ops))
+	assert.Equal(t, []float64{1, 2, 3}, scalars(t, stack))
+
+	stack.Clear()
+	stack.Push(81)
+	pushLiteral(t, stack, "[16 25]")
+	assert.Nil(t, tryCombinator("map sqrt", stack, ops))
+	assert.Equal(t, "[ 9  [4 5] ]", stack.String())
+
+	stack.Clear()
+	pushAll(stack, 32, 212)
+	assert.Nil(t, tryCombinator("map fc", stack, ops))
+	results := scalars(t, stack)
+	assertClose(t, 0, results[0])
+	assertClose(t, 100, results[1])
+}
+
+func TestMapLeavesStackOnError(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2)
+	assert.True(t, isFailed(tryCombinator("map +", stack, ops)))
+	assert.Equal(t, []float64{1, 2}, scalars(t, stack))
+	assert.True(t, isFailed(tryCombinator("map nosuchop", stack, ops)))
+	assert.NotNil(t, mapStack(stack, plusOp))
+
+	assert.True(t, isFailed(cascade("map +", stack, ops)))
+	assert.Empty(t, stack.repeat.lastOp)
+	assert.Empty(t, stack.history.snapshots)
+}
+
+func TestFold(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 3, 4)
+	assert.Nil(t, tryCombinator("fold *", stack, ops))
+	assert.Equal(t, []float64{24}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 10, 3, 2)
+	assert.Nil(t, tryCombinator("fold -", stack, ops))
+	assert.Equal(t, []float64{5}, scalars(t, stack))
+
+	stack.Clear()
+	assert.NotNil(t, foldStack(stack, plusOp))
+
+	// registers the op changes are kept, and undone with the line
+	pushAll(stack, 1, 2, 3)
+	assert.Nil(t, cascade("fold Σ+", stack, ops))
+	assert.Equal(t, 2.0, stack.sigma.n)
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, 0.0, stack.sigma.n)
+	assert.Equal(t, []float64{1, 2, 3}, scalars(t, stack))
+}
+
+func TestZip(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 3, 10, 20, 30)
+	assert.Nil(t, ops.Run("zip", stack))
+	assert.Equal(t, []float64{1, 10, 2, 20, 3, 30}, scalars(t, stack))
+
+	stack.Push(7)
+	assert.NotNil(t, ops.Run("zip", stack))
+}
+
+func TestIota(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(4)
+	assert.Nil(t, ops.Run("iota", stack))
+	assert.Equal(t, []float64{1, 2, 3, 4}, scalars(t, stack))
+
+	stack.Clear()
+	assert.Nil(t, tryCombinator("iota 3", stack, ops))
+	assert.Equal(t, []float64{1, 2, 3}, scalars(t, stack))
+}
+
+func TestArange(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.Nil(t, tryCombinator("range 1 10 3", stack, ops))
+	assert.Equal(t, []float64{1, 4, 7, 10}, scalars(t, stack))
+
+	stack.Clear()
+	assert.Nil(t, tryCombinator("range 0 1 0.1", stack, ops))
+	assert.Equal(t, 11, stack.Len())
+	assertClose(t, 1, topOf(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 5, 1, -2)
+	assert.Nil(t, ops.Run("arange", stack))
+	assert.Equal(t, []float64{5, 3, 1}, scalars(t, stack))
+
+	stack.Clear()
+	stack.Push(42)
+	assert.True(t, isFailed(tryCombinator("range 1 10 -1", stack, ops)))
+	assert.Equal(t, []float64{42}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 1, 5)
+	assert.Nil(t, ops.Run("range", stack))
+	assert.Equal(t, []float64{1, 5, 4}, scalars(t, stack))
+}
+
+func TestLinspace(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.Nil(t, tryCombinator("linspace 0 1 5", stack, ops))
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75, 1}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 2, 3, 1)
+	assert.Nil(t, ops.Run("linspace", stack))
+	assert.Equal(t, []float64{2}, scalars(t, stack))
+}
@@ -8,10 +8,14 @@ import (
 )
 
 const (
+	_c              = 299792458.0
 	_ftPerM         = 3.280839895
 	_jPerFtLb       = 1.3558179483314004
+	_kelvinOffset   = 273.15
 	_lPerGal        = 3.785411784
 	_pPerKg         = 0.45359237
+	_paPerHPa       = 100.0
+	_paPerInHg      = 3386.389
 	_wPerHp         = 745.699872
 	_defaultMaxRand = math.MaxInt16
 )
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+)
+
+// Dates such as 2026-10-17 and timestamps such as 2026-10-17T09:30 are read
+// in the stack's zone, local time unless changed with zone. Durations are
+// written 90d, 3h15m or 1w2d, and a day is a calendar day when added to a
+// date, so 1d moves across a daylight saving change to the same wall clock
+// time. Durations scale by numbers, and a number divided by a duration is a
+// rate per second, such as a speed. The embedded tzdata means zone names
+// resolve without a system zone database.
+
+var (
+	_durationRe = regexp.MustCompile(`^(-)?(?:(\d+(?:\.\d+)?)w)?(?:(\d+(?:\.\d+)?)d)?((?:\d+(?:\.\d+)?(?:h|m|s|ms|us|µs|ns))*)$`)
+
+	_timeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02T15:04",
+	}
+)
+
+const (
+	_dateLayout = "2006-01-02"
+	_day        = 24 * time.Hour
+)
+
+type (
+	Time struct {
+		t        time.Time
+		dateOnly bool
+	}
+
+	Duration time.Duration
+)
+
+func (t Time) Format(format func(float64) string) string {
+	if t.dateOnly {
+		return t.t.Format(_dateLayout)
+	}
+	return t.t.Format(time.RFC3339)
+}
+
+func (d Duration) Format(format func(float64) string) string {
+	duration := time.Duration(d)
+	sign := ""
+	days := duration / _day
+	rest := duration % _day
+	// Negating the quotient and remainder rather than the duration keeps the
+	// most negative duration, which has no positive counterpart, in range.
+	if duration < 0 {
+		sign = "-"
+		days, rest = -days, -rest
+	}
+	switch {
+	case days == 0:
+		return sign + rest.String()
+	case rest == 0:
+		return fmt.Sprintf("%s%dd", sign, days)
+	}
+	return fmt.Sprintf("%s%dd%s", sign, days, rest)
+}
+
+// sumDurations adds two non-negative durations, reporting false on overflow.
+func sumDurations(x, y time.Duration) (time.Duration, bool) {
+	if x > math.MaxInt64-y {
+		return 0, false
+	}
+	return x + y, true
+}
+
+func parseDuration(text string) (Duration, error) {
+	match := _durationRe.FindStringSubmatch(text)
+	if match == nil || len(text) <= 0 || text == "-" {
+		return 0, fmt.Errorf("'%s' is not a duration", text)
+	}
+	total := time.Duration(0)
+	inRange := true
+	for i, unit := range []time.Duration{7 * _day, _day} {
+		if len(match[2+i]) > 0 {
+			n, err := strconv.ParseFloat(match[2+i], 64)
+			if err != nil {
+				return 0, err
+			}
+			scaled := math.Round(n * float64(unit))
+			if scaled >= math.MaxInt64 {
+				return 0, fmt.Errorf("'%s' is out of range", text)
+			}
+			if total, inRange = sumDurations(total, time.Duration(scaled)); !inRange {
+				return 0, fmt.Errorf("'%s' is out of range", text)
+			}
+		}
+	}
+	if len(match[4]) > 0 {
+		rest, err := time.ParseDuration(match[4])
+		if err != nil {
+			return 0, err
+		}
+		if total, inRange = sumDurations(total, rest); !inRange {
+			return 0, fmt.Errorf("'%s' is out of range", text)
+		}
+	}
+	if len(match[1]) > 0 {
+		total = -total
+	}
+	return Duration(total), nil
+}
+
+func parseTime(text string, zone *time.Location) (Time, error) {
+	if t, err := time.ParseInLocation(_dateLayout, text, zone); err == nil {
+		return Time{t, true}, nil
+	}
+	for _, layout := range _timeLayouts {
+		if t, err := time.ParseInLocation(layout, text, zone); err == nil {
+			return Time{t, false}, nil
+		}
+	}
+	return Time{}, fmt.Errorf("'%s' is not a date or time", text)
+}
+
+func tryTime(line string, stack *Stack) error {
+	t, err := parseTime(line, stack.zone)
+	if err == nil {
+		stack.PushValue(t)
+		return nil
+	}
+	d, err := parseDuration(line)
+	if err == nil {
+		stack.PushValue(d)
+		return nil
+	}
+	return fmt.Errorf("not a date, time or duration")
+}
+
+// addDuration adds whole days on the calendar and the rest on the clock.
+func addDuration(t Time, d Duration) Time {
+	duration := time.Duration(d)
+	days := duration / _day
+	rest := duration % _day
+	result := t.t.AddDate(0, 0, int(days)).Add(rest)
+	return Time{result, t.dateOnly && rest == 0}
+}
+
+// wallClock reads t's wall clock time as if it were UTC, so that differences
+// count calendar days regardless of daylight saving.
+func wallClock(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	return time.Date(year, month, day, hour, minute, second, t.Nanosecond(), time.UTC)
+}
+
+func timeAdd(stack *Stack, x, y Value) (Value, bool, error) {
+	switch xt := x.(type) {
+	case Time:
+		if yd, ok := y.(Duration); ok {
+			return addDuration(xt, yd), true, nil
+		}
+	case Duration:
+		switch yt := y.(type) {
+		case Time:
+			return addDuration(yt, xt), true, nil
+		case Duration:
+			sum := xt + yt
+			if (yt > 0 && sum < xt) || (yt < 0 && sum > xt) {
+				return nil, true, fmt.Errorf("%s plus %s is out of range", xt.Format(nil), yt.Format(nil))
+			}
+			return sum, true, nil
+		}
+	}
+	return timeMismatch(x, y)
+}
+
+func timeSub(stack *Stack, x, y Value) (Value, bool, error) {
+	switch xt := x.(type) {
+	case Time:
+		switch yt := y.(type) {
+		case Duration:
+			return addDuration(xt, -yt), true, nil
+		case Time:
+			if xt.dateOnly && yt.dateOnly {
+				return Duration(wallClock(xt.t).Sub(wallClock(yt.t))), true, nil
+			}
+			return Duration(xt.t.Sub(yt.t)), true, nil
+		}
+	case Duration:
+		if yd, ok := y.(Duration); ok {
+			difference := xt - yd
+			if (yd > 0 && difference > xt) || (yd < 0 && difference < xt) {
+				return nil, true, fmt.Errorf("%s minus %s is out of range", xt.Format(nil), yd.Format(nil))
+			}
+			return difference, true, nil
+		}
+	}
+	return timeMismatch(x, y)
+}
+
+// timeMismatch rejects a date or duration paired with anything the time
+// hooks did not handle, and passes plain numbers on.
+func timeMismatch(x, y Value) (Value, bool, error) {
+	for _, v := range []Value{x, y} {
+		switch v.(type) {
+		case Time, Duration:
+			return nil, true, fmt.Errorf("cannot combine %s with %s", describeKind(x), describeKind(y))
+		}
+	}
+	return nil, false, nil
+}
+
+// scaleDuration multiplies d by n, rounding to the nearest nanosecond.
+func scaleDuration(d Duration, n float64) (Value, bool, error) {
+	scaled := math.Round(float64(d) * n)
+	if math.IsNaN(scaled) || math.Abs(scaled) >= math.MaxInt64 {
+		return nil, true, fmt.Errorf("%s times %g is out of range", d.Format(nil), n)
+	}
+	return Duration(scaled), true, nil
+}
+
+func durationMul(stack *Stack, x, y Value) (Value, bool, error) {
+	if xd, ok := x.(Duration); ok {
+		if n, ok := scalarOf(y); ok {
+			return scaleDuration(xd, n)
+		}
+	}
+	if yd, ok := y.(Duration); ok {
+		if n, ok := scalarOf(x); ok {
+			return scaleDuration(yd, n)
+		}
+	}
+	return timeMismatch(x, y)
+}
+
+// durationDiv divides a duration into equal parts, two durations into a
+// ratio, and a distance by a duration into a speed in distance per second.
+func durationDiv(stack *Stack, x, y Value) (Value, bool, error) {
+	xd, xIsDuration := x.(Duration)
+	yd, yIsDuration := y.(Duration)
+	if yIsDuration && yd == 0 {
+		return nil, true, fmt.Errorf("division by a zero duration")
+	}
+	switch {
+	case xIsDuration && yIsDuration:
+		return Scalar(float64(xd) / float64(yd)), true, nil
+	case xIsDuration:
+		n, ok := scalarOf(y)
+		if !ok {
+			break
+		}
+		if n == 0 {
+			return nil, true, fmt.Errorf("division of a duration by zero")
+		}
+		return scaleDuration(xd, 1/n)
+	case yIsDuration:
+		distance, ok := scalarOf(x)
+		if !ok {
+			break
+		}
+		return Scalar(distance / time.Duration(yd).Seconds()), true, nil
+	}
+	return timeMismatch(x, y)
+}
+
+// spelledDuration writes d as days, hours, minutes and seconds, leaving out
+// the zero parts, as in 1d 2h 3m.
+func spelledDuration(d Duration) string {
+	duration := time.Duration(d)
+	sign := ""
+	if duration < 0 {
+		sign = "-"
+		duration = -duration
+	}
+	parts := []string{}
+	for _, unit := range []struct {
+		size time.Duration
+		name string
+	}{{_day, "d"}, {time.Hour, "h"}, {time.Minute, "m"}} {
+		if n := duration / unit.size; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.name))
+			duration -= n * unit.size
+		}
+	}
+	if duration > 0 || len(parts) == 0 {
+		parts = append(parts, strconv.FormatFloat(duration.Seconds(), 'f', -1, 64)+"s")
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+func popTime(stack *Stack) (Time, error) {
+	top, err := stack.TopValue()
+	if err != nil {
+		return Time{}, err
+	}
+	t, ok := top.(Time)
+	if !ok {
+		return Time{}, fmt.Errorf("expected a date or time but found %s", describeKind(top))
+	}
+	_, _ = stack.PopValue()
+	return t, nil
+}
+
+func popTimes(stack *Stack) (Time, Time, error) {
+	elems, err := stack.PopValues(2)
+	if err != nil {
+		return Time{}, Time{}, err
+	}
+	from, fromOk := elems[0].(Time)
+	to, toOk := elems[1].(Time)
+	if !fromOk || !toOk {
+		return Time{}, Time{}, fmt.Errorf("expected two dates but found %s and %s", describeKind(elems[0]), describeKind(elems[1]))
+	}
+	return from, to, nil
+}
+
+// days counts calendar days from from to to, with any time of day as a
+// fraction.
+func days(from, to Time) float64 {
+	return wallClock(to.t).Sub(wallClock(from.t)).Hours() / 24
+}
+
+func isWeekday(t time.Time) bool {
+	weekday := t.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// businessDays counts the weekdays from from up to but not including to,
+// negative when to comes first.
+func businessDays(from, to Time) int {
+	start := wallClock(from.t).Truncate(_day)
+	end := wallClock(to.t).Truncate(_day)
+	sign := 1
+	if end.Before(start) {
+		start, end = end, start
+		sign = -1
+	}
+	total := int(end.Sub(start) / _day)
+	count := total / 7 * 5
+	for d := start.AddDate(0, 0, total/7*7); d.Before(end); d = d.AddDate(0, 0, 1) {
+		if isWeekday(d) {
+			count++
+		}
+	}
+	return sign * count
+}
+
+func loadZone(name string) (*time.Location, error) {
+	if strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// tryZone handles tz <zone>, which converts the time on top of the stack,
+// and zone <zone>, which sets the zone for new dates and times.
+func tryZone(line string, stack *Stack) error {
+	fields := strings.Fields(line)
+	if len(fields) != 2 || (fields[0] != "tz" && fields[0] != "zone") {
+		return fmt.Errorf("not a zone command")
+	}
+	zone, err := loadZone(fields[1])
+	if err != nil {
+		return failed(err)
+	}
+	if fields[0] == "zone" {
+		stack.zone = zone
+		return nil
+	}
+	t, err := popTime(stack)
+	if err != nil {
+		return failed(err)
+	}
+	stack.PushValue(Time{t.t.In(zone), false})
+	return nil
+}
+
+var (
+	nowOp = Op{
+		"push the current time",
+		func(stack *Stack) (Floats, error) {
+			stack.PushValue(Time{time.Now().In(stack.zone), false})
+			return nil, nil
+		},
+	}
+
+	todayOp = Op{
+		"push today's date",
+		func(stack *Stack) (Floats, error) {
+			year, month, day := time.Now().In(stack.zone).Date()
+			stack.PushValue(Time{time.Date(year, month, day, 0, 0, 0, 0, stack.zone), true})
+			return nil, nil
+		},
+	}
+
+	daysOp = Op{
+		"from to days, the number of days between two dates",
+		func(stack *Stack) (Floats, error) {
+			from, to, err := popTimes(stack)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{days(from, to)}, nil
+		},
+	}
+
+	businessDaysOp = Op{
+		"from to bdays, the number of weekdays from the first date up to the second",
+		func(stack *Stack) (Floats, error) {
+			from, to, err := popTimes(stack)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{float64(businessDays(from, to))}, nil
+		},
+	}
+
+	dowOp = Op{
+		"print the weekday of a date and push its ISO number, 1 for Monday to 7 for Sunday",
+		func(stack *Stack) (Floats, error) {
+			t, err := popTime(stack)
+			if err != nil {
+				return nil, err
+			}
+			weekday := t.t.Weekday()
+			fmt.Println(weekday)
+			if weekday == time.Sunday {
+				return Floats{7}, nil
+			}
+			return Floats{float64(weekday)}, nil
+		},
+	}
+
+	isoWeekOp = Op{
+		"print the ISO year and week of a date and push the week number",
+		func(stack *Stack) (Floats, error) {
+			t, err := popTime(stack)
+			if err != nil {
+				return nil, err
+			}
+			year, week := t.t.ISOWeek()
+			fmt.Printf("%d-W%02d\n", year, week)
+			return Floats{float64(week)}, nil
+		},
+	}
+
+	toEpochOp = Op{
+		"seconds since the Unix epoch of a date or time",
+		func(stack *Stack) (Floats, error) {
+			t, err := popTime(stack)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{float64(t.t.UnixNano()) / 1e9}, nil
+		},
+	}
+
+	fromEpochOp = Op{
+		"the time stack.Top() seconds after the Unix epoch",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if math.IsNaN(top) || math.Abs(top) > math.MaxInt64/1e9 {
+				return nil, fmt.Errorf("%g seconds is outside the range of a time", top)
+			}
+			seconds, fraction := math.Modf(top)
+			t := time.Unix(int64(seconds), int64(math.Round(fraction*1e9))).In(stack.zone)
+			stack.PushValue(Time{t, false})
+			return nil, nil
+		},
+	}
+
+	showDurationOp = Op{
+		"print the duration on top of the stack in days, hours, minutes and seconds",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.TopValue()
+			if err != nil {
+				return nil, err
+			}
+			d, ok := top.(Duration)
+			if !ok {
+				return nil, fmt.Errorf("expected a duration but found %s", describeKind(top))
+			}
+			fmt.Println(spelledDuration(d))
+			return nil, nil
+		},
+	}
+
+	toSecondsOp = Op{
+		"the length of a duration in seconds",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			d, ok := top.(Duration)
+			if !ok {
+				return nil, fmt.Errorf("expected a duration but found %s", describeKind(top))
+			}
+			return Floats{time.Duration(d).Seconds()}, nil
+		},
+	}
+
+	fromSecondsOp = Op{
+		"the duration of stack.Top() seconds",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			result, _, err := scaleDuration(Duration(time.Second), top)
+			if err != nil {
+				return nil, err
+			}
+			stack.PushValue(result)
+			return nil, nil
+		},
+	}
+
+	tzOp = Op{
+		"tz <zone>, convert the time on top of the stack to a zone such as Europe/Paris",
+		func(stack *Stack) (Floats, error) {
+			return nil, fmt.Errorf("usage: tz <zone>")
+		},
+	}
+
+	zoneOp = Op{
+		"zone <zone>, read new dates and times in a zone such as UTC or local",
+		func(stack *Stack) (Floats, error) {
+			fmt.Println(stack.zone)
+			return nil, nil
+		},
+	}
+)
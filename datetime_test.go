@@ -0,0 +1,214 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func utcStack() *Stack {
+	stack := NewStack()
+	stack.zone = time.UTC
+	return stack
+}
+
+func TestDateLiterals(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "2026-10-17", "2026-10-17T09:30", "2026-10-17T09:30:15-07:00")
+	assert.Equal(t, "[ 2026-10-17  2026-10-17T09:30:00Z  2026-10-17T09:30:15-07:00 ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "90d", "3h15m", "1w2d", "-1d12h")
+	assert.Equal(t, "[ 90d  3h15m0s  9d  -1d12h0m0s ]", stack.String())
+
+	_, err := parseDuration("5")
+	assert.NotNil(t, err)
+	_, err = parseDuration("-")
+	assert.NotNil(t, err)
+	_, err = parseDuration("1000000w")
+	assert.NotNil(t, err)
+	_, err = parseDuration("9999999999999999999999d")
+	assert.NotNil(t, err)
+	_, err = parseDuration("106751d23h47m16.854775807s")
+	assert.Nil(t, err)
+	_, err = parseDuration("106751d23h47m16.854775808s")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, "-106751d23h47m16.854775808s", Duration(math.MinInt64).Format(nil))
+}
+
+func TestDateArithmetic(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "2026-10-17", "90d", "+")
+	assert.Equal(t, "[ 2027-01-15 ]", stack.String())
+	runAll(t, stack, ops, "90d", "-")
+	assert.Equal(t, "[ 2026-10-17 ]", stack.String())
+	runAll(t, stack, ops, "3h15m", "+")
+	assert.Equal(t, "[ 2026-10-17T03:15:00Z ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "1d", "2026-02-28", "+", "2026-01-01", "-")
+	assert.Equal(t, "[ 59d ]", stack.String())
+	runAll(t, stack, ops, "2h", "+")
+	assert.Equal(t, "[ 59d2h0m0s ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "2026-10-17", "5")
+	assert.NotNil(t, ops.Run("+", stack))
+	assert.Equal(t, 2, stack.Len())
+}
+
+func TestDateAcrossDaylightSaving(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	zone, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	stack.zone = zone
+	runAll(t, stack, ops, "2026-03-07T12:00", "1d", "+")
+	assert.Equal(t, "[ 2026-03-08T12:00:00-04:00 ]", stack.String())
+	runAll(t, stack, ops, "2026-03-07T12:00", "-")
+	assert.Equal(t, "[ 23h0m0s ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "2026-03-01", "2026-03-31", "days")
+	assert.Equal(t, []float64{30}, scalars(t, stack))
+}
+
+func TestDaysAndWeekdays(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "2026-10-17", "2026-12-25", "days")
+	assert.Equal(t, []float64{69}, scalars(t, stack))
+	runAll(t, stack, ops, "2026-12-25", "2026-10-17", "days")
+	assert.Equal(t, -69.0, stack.PopU())
+	runAll(t, stack, ops, "2026-10-17", "2026-10-18T06:00", "days")
+	assert.Equal(t, 1.25, stack.PopU())
+
+	stack.Clear()
+	runAll(t, stack, ops, "2026-10-17", "dow")
+	assert.Equal(t, []float64{6}, scalars(t, stack))
+	runAll(t, stack, ops, "2026-10-18", "dow")
+	assert.Equal(t, 7.0, stack.PopU())
+
+	runAll(t, stack, ops, "2026-01-01", "isoweek", "2027-01-01", "isoweek", "2026-10-17", "isoweek")
+	assert.Equal(t, []float64{6, 1, 53, 42}, scalars(t, stack))
+}
+
+func TestBusinessDays(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	tests := []struct {
+		from, to string
+		count    float64
+	}{
+		{"2026-10-19", "2026-10-26", 5},
+		{"2026-10-17", "2026-10-19", 0},
+		{"2026-10-16", "2026-10-20", 2},
+		{"2026-10-01", "2026-11-01", 22},
+		{"2026-10-26", "2026-10-19", -5},
+		{"2026-10-19", "2026-10-19", 0},
+	}
+	for _, test := range tests {
+		runAll(t, stack, ops, test.from, test.to, "bdays")
+		assert.Equal(t, test.count, stack.PopU(), test.from+" "+test.to)
+	}
+}
+
+func TestEpoch(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "2026-10-17", ">epoch")
+	assert.Equal(t, []float64{1792195200}, scalars(t, stack))
+	runAll(t, stack, ops, "epoch>")
+	assert.Equal(t, "[ 2026-10-17T00:00:00Z ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "1.5", "epoch>", ">epoch")
+	assert.Equal(t, []float64{1.5}, scalars(t, stack))
+}
+
+func TestZones(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "2026-10-17T12:00", "tz Asia/Tokyo")
+	assert.Equal(t, "[ 2026-10-17T21:00:00+09:00 ]", stack.String())
+	assert.True(t, isFailed(cascade("tz Nowhere/Special", stack, ops)))
+	assert.Equal(t, "[ 2026-10-17T21:00:00+09:00 ]", stack.String())
+	runAll(t, stack, ops, "5")
+	assert.True(t, isFailed(cascade("tz UTC", stack, ops)))
+	assert.Equal(t, "[ 2026-10-17T21:00:00+09:00  5 ]", stack.String())
+	runAll(t, stack, ops, "pop")
+
+	runAll(t, stack, ops, "zone Europe/Paris", "2026-10-17T05:00", "-")
+	assert.Equal(t, "[ 9h0m0s ]", stack.String())
+}
+
+func TestDurationArithmetic(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1h30m", "45s", "+", "250ms", "+")
+	assert.Equal(t, "[ 1h30m45.25s ]", stack.String())
+	runAll(t, stack, ops, "2", "*")
+	assert.Equal(t, "[ 3h1m30.5s ]", stack.String())
+	runAll(t, stack, ops, "4", "/")
+	assert.Equal(t, "[ 45m22.625s ]", stack.String())
+	runAll(t, stack, ops, "3", "sw", "*")
+	assert.Equal(t, "[ 2h16m7.875s ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "1h30m", "45m", "/")
+	assert.Equal(t, []float64{2}, scalars(t, stack))
+
+	stack.Clear()
+	runAll(t, stack, ops, "1h", "0")
+	assert.NotNil(t, ops.Run("/", stack))
+	assert.Equal(t, 2, stack.Len())
+
+	stack.Clear()
+	runAll(t, stack, ops, "1h", "1h")
+	assert.NotNil(t, ops.Run("*", stack))
+	assert.Equal(t, 2, stack.Len())
+
+	// durations reach about 292 years either way
+	stack.Clear()
+	runAll(t, stack, ops, "100000d", "100000d")
+	assert.NotNil(t, ops.Run("+", stack))
+	assert.Equal(t, 2, stack.Len())
+
+	stack.Clear()
+	runAll(t, stack, ops, "0s", "100000d", "-", "100000d")
+	assert.NotNil(t, ops.Run("-", stack))
+	assert.Equal(t, 2, stack.Len())
+}
+
+func TestSpeed(t *testing.T) {
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "100", "9.58s", "/")
+	assertClose(t, 10.438413361169102, stack.PopU())
+
+	runAll(t, stack, ops, "26.2", "2h30m", "/", "3600", "*")
+	assertClose(t, 10.48, stack.PopU())
+
+	runAll(t, stack, ops, "1.5", "sec>", ">sec")
+	assert.Equal(t, []float64{1.5}, scalars(t, stack))
+}
+
+func TestSpelledDuration(t *testing.T) {
+	assert.Equal(t, "1d 2h 3m", spelledDuration(Duration(26*time.Hour+3*time.Minute)))
+	assert.Equal(t, "1d 4.5s", spelledDuration(Duration(24*time.Hour+4500*time.Millisecond)))
+	assert.Equal(t, "-2h", spelledDuration(Duration(-2*time.Hour)))
+	assert.Equal(t, "0.25s", spelledDuration(Duration(250*time.Millisecond)))
+	assert.Equal(t, "0s", spelledDuration(0))
+
+	stack := utcStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1d2h3m", ">dhms")
+	assert.Equal(t, 1, stack.Len())
+	runAll(t, stack, ops, "5")
+	assert.NotNil(t, ops.Run(">dhms", stack))
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// In decimal mode number literals are pushed as exact Decimals. + - and *
+// keep them exact and / rounds its quotient to the scale, so 0.1 0.2 + is
+// exactly 0.3. A Decimal goes through every other op as the nearest float64.
+// The scale and rounding also govern how the stack is displayed.
+
+type DecimalRounding int
+
+const (
+	DecimalHalfEven DecimalRounding = iota
+	DecimalHalfUp
+)
+
+const (
+	_defaultDecimalScale = 2
+	_maxDecimalScale     = 100
+)
+
+var _decimalRe = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?$`)
+
+type Decimal struct {
+	rat *big.Rat
+}
+
+type DecimalConfig struct {
+	enabled  bool
+	scale    int
+	rounding DecimalRounding
+}
+
+func NewDecimalConfig() DecimalConfig {
+	return DecimalConfig{scale: _defaultDecimalScale}
+}
+
+func (d Decimal) Float() float64 {
+	f, _ := d.rat.Float64()
+	return f
+}
+
+// Format shows d as its float64, except that a whole number too long for a
+// float64, such as an exact factorial, keeps all its digits.
+func (d Decimal) Format(format func(float64) string) string {
+	if f, exact := d.rat.Float64(); d.rat.IsInt() && !exact && !math.IsInf(f, 0) {
+		return d.rat.Num().String()
+	}
+	return format(d.Float())
+}
+
+func parseDecimal(text string) (Decimal, error) {
+	if !_decimalRe.MatchString(text) {
+		return Decimal{}, fmt.Errorf("'%s' is not a decimal literal", text)
+	}
+	rat, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return Decimal{}, fmt.Errorf("'%s' is not a decimal literal", text)
+	}
+	return Decimal{rat}, nil
+}
+
+// toDecimal takes a Scalar at its shortest decimal spelling, so 0.1 is one
+// tenth rather than the binary fraction nearest to it.
+func toDecimal(v Value) (Decimal, bool) {
+	switch t := v.(type) {
+	case Decimal:
+		return t, true
+	case Scalar:
+		x := float64(t)
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			return Decimal{}, false
+		}
+		d, err := parseDecimal(strconv.FormatFloat(x, 'g', -1, 64))
+		return d, err == nil
+	}
+	return Decimal{}, false
+}
+
+// round rounds x to scale digits after the point. Half up rounds ties away
+// from zero, the usual commercial rule.
+func (c DecimalConfig) round(x *big.Rat) *big.Rat {
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.scale)), nil)
+	num := new(big.Int).Mul(x.Num(), pow)
+	q, r := new(big.Int).QuoRem(num, x.Denom(), new(big.Int))
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(x.Denom())
+	if cmp > 0 || cmp == 0 && (c.rounding == DecimalHalfUp || q.Bit(0) == 1) {
+		if x.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return new(big.Rat).SetFrac(q, pow)
+}
+
+func (c DecimalConfig) String(d Decimal) string {
+	return c.round(d.rat).FloatString(c.scale)
+}
+
+// FormatValue shows every number at the fixed scale.
+func (c DecimalConfig) FormatValue(v Value) string {
+	if d, ok := toDecimal(v); ok {
+		return c.String(d)
+	}
+	return v.Format(func(x float64) string {
+		if d, ok := toDecimal(Scalar(x)); ok {
+			return c.String(d)
+		}
+		return fmt.Sprint(x)
+	})
+}
+
+// decimalArith applies exact to two decimal operands, rounding a quotient
+// to the scale. It reports false when either operand is not a number or
+// neither is a Decimal, and the float op should run instead. Outside decimal
+// mode there is no scale to round to, so a quotient is left to the float op.
+func decimalArith(stack *Stack, x, y Value, exact func(*big.Rat, *big.Rat) (*big.Rat, bool, error)) (Value, bool, error) {
+	_, xIsDecimal := x.(Decimal)
+	_, yIsDecimal := y.(Decimal)
+	if !xIsDecimal && !yIsDecimal {
+		return nil, false, nil
+	}
+	xd, ok := toDecimal(x)
+	if !ok {
+		return nil, false, nil
+	}
+	yd, ok := toDecimal(y)
+	if !ok {
+		return nil, false, nil
+	}
+	result, inexact, err := exact(xd.rat, yd.rat)
+	if err != nil {
+		return nil, true, err
+	}
+	if inexact && !stack.decimal.enabled {
+		return nil, false, nil
+	}
+	if inexact {
+		result = stack.decimal.round(result)
+	}
+	return Decimal{result}, true, nil
+}
+
+func decimalHook(exact func(*big.Rat, *big.Rat) (*big.Rat, bool, error)) arithHook {
+	return func(stack *Stack, x, y Value) (Value, bool, error) {
+		return decimalArith(stack, x, y, exact)
+	}
+}
+
+func decimalAdd(x, y *big.Rat) (*big.Rat, bool, error) {
+	return new(big.Rat).Add(x, y), false, nil
+}
+
+func decimalSub(x, y *big.Rat) (*big.Rat, bool, error) {
+	return new(big.Rat).Sub(x, y), false, nil
+}
+
+func decimalMul(x, y *big.Rat) (*big.Rat, bool, error) {
+	return new(big.Rat).Mul(x, y), false, nil
+}
+
+func decimalDiv(x, y *big.Rat) (*big.Rat, bool, error) {
+	if y.Sign() == 0 {
+		return nil, false, fmt.Errorf("decimal division by zero")
+	}
+	return new(big.Rat).Quo(x, y), true, nil
+}
+
+func tryDecimal(line string, stack *Stack) error {
+	if !stack.decimal.enabled {
+		return fmt.Errorf("decimal mode is off")
+	}
+	d, err := parseDecimal(line)
+	if err != nil {
+		return err
+	}
+	stack.PushValue(d)
+	return nil
+}
+
+var (
+	decimalOnOp = Op{
+		"decimal mode, number literals are exact and the stack shows a fixed scale",
+		func(stack *Stack) (Floats, error) {
+			stack.decimal.enabled = true
+			return nil, nil
+		},
+	}
+
+	decimalOffOp = Op{
+		"leave decimal mode, new literals are float64 again",
+		func(stack *Stack) (Floats, error) {
+			stack.decimal.enabled = false
+			return nil, nil
+		},
+	}
+
+	decimalScaleOp = Op{
+		"n scale, digits after the point for decimal division, rounding and display",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if top < 0 || top > _maxDecimalScale || top != math.Trunc(top) {
+				return nil, fmt.Errorf("scale must be an integer from 0 to %d", _maxDecimalScale)
+			}
+			stack.decimal.scale = int(top)
+			return nil, nil
+		},
+	}
+
+	decimalHalfEvenOp = wrapDecimalRoundingOp("round decimals half to even, banker's rounding", DecimalHalfEven)
+	decimalHalfUpOp   = wrapDecimalRoundingOp("round decimals half away from zero", DecimalHalfUp)
+)
+
+func wrapDecimalRoundingOp(doc string, rounding DecimalRounding) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			stack.decimal.rounding = rounding
+			return nil, nil
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalArithmetic(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "0.1", "0.2", "+")
+	assert.Equal(t, "[ 0.30000000000000004 ]", stack.String())
+
+	stack.Clear()
+	runAll(t, stack, ops, "dec", "0.1", "0.2", "+")
+	top, err := stack.TopValue()
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewRat(3, 10), top.(Decimal).rat)
+	assert.Equal(t, "[ 0.30 ]", stack.String())
+
+	runAll(t, stack, ops, "1.005", "-")
+	assert.Equal(t, big.NewRat(-141, 200), stack.storage[0].(Decimal).rat)
+
+	stack.Clear()
+	runAll(t, stack, ops, "19.99", "3", "*", "0.0825", "*")
+	assert.Equal(t, "4.9475250", stack.storage[0].(Decimal).rat.FloatString(7))
+	assert.Equal(t, "[ 4.95 ]", stack.String())
+}
+
+func TestDecimalDivision(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "dec", "10", "3", "/")
+	assert.Equal(t, big.NewRat(333, 100), stack.storage[0].(Decimal).rat)
+
+	runAll(t, stack, ops, "4", "scale", "2", "3", "/")
+	assert.Equal(t, big.NewRat(6667, 10000), stack.storage[1].(Decimal).rat)
+	assert.Equal(t, "[ 3.3300  0.6667 ]", stack.String())
+
+	runAll(t, stack, ops, "0")
+	assert.NotNil(t, ops.Run("/", stack))
+	assert.Equal(t, 3, stack.Len())
+}
+
+func TestDecimalRounding(t *testing.T) {
+	config := NewDecimalConfig()
+	half := func(text string) string {
+		d, err := parseDecimal(text)
+		assert.Nil(t, err)
+		return config.String(d)
+	}
+	assert.Equal(t, "0.12", half("0.125"))
+	assert.Equal(t, "0.14", half("0.135"))
+	assert.Equal(t, "-0.12", half("-0.125"))
+	assert.Equal(t, "2.50", half("2.5"))
+
+	config.rounding = DecimalHalfUp
+	assert.Equal(t, "0.13", half("0.125"))
+	assert.Equal(t, "-0.13", half("-0.125"))
+	assert.Equal(t, "0.12", half("0.1249"))
+
+	config.scale = 0
+	assert.Equal(t, "3", half("2.5"))
+	config.rounding = DecimalHalfEven
+	assert.Equal(t, "2", half("2.5"))
+	assert.Equal(t, "4", half("3.5"))
+}
+
+func TestDecimalMixesWithFloats(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "dec", "2.25", "sqrt")
+	assert.Equal(t, []float64{1.5}, scalars(t, stack))
+	runAll(t, stack, ops, "0.1", "+")
+	assert.Equal(t, big.NewRat(8, 5), stack.storage[0].(Decimal).rat)
+
+	runAll(t, stack, ops, "[1 2]", "*")
+	assert.Equal(t, "[ [1.60 3.20] ]", stack.String())
+
+	runAll(t, stack, ops, "nodec", "0.1")
+	_, ok := stack.storage[1].(Scalar)
+	assert.True(t, ok)
+
+	_, err := parseDecimal("1e")
+	assert.NotNil(t, err)
+}
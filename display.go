@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type DisplayMode int
+
+const (
+	DisplayStandard DisplayMode = iota
+	DisplayFraction
+	DisplayInch
+)
+
+const (
+	_defaultMaxDenominator  = 1000000
+	_defaultFracTolerance   = 1e-6
+	_defaultInchDenominator = 64
+	_maxFracTerms           = 64
+	_maxFracValue           = 1e9
+	_maxFracDenominator     = 1e9
+)
+
+// Display controls how Stack.String renders values. Fractions are found with
+// continued fractions, bounded by a maximum denominator and a relative
+// tolerance, and are prefixed with ≈ when they are not exact.
+type Display struct {
+	mode            DisplayMode
+	maxDenominator  int64
+	tolerance       float64
+	inchDenominator int64
+}
+
+func NewDisplay() Display {
+	return Display{
+		mode:            DisplayStandard,
+		maxDenominator:  _defaultMaxDenominator,
+		tolerance:       _defaultFracTolerance,
+		inchDenominator: _defaultInchDenominator,
+	}
+}
+
+func (d *Display) Format(value float64) string {
+	switch d.mode {
+	case DisplayFraction:
+		return d.Fraction(value)
+	case DisplayInch:
+		return d.Inch(value)
+	default:
+		return fmt.Sprintf("%g", value)
+	}
+}
+
+// rationalApprox returns the first continued fraction convergent of x within
+// tolerance of x, or the best semiconvergent whose denominator does not
+// exceed maxDen.
+func rationalApprox(x float64, maxDen int64, tolerance float64) (int64, int64) {
+	p0, q0, p1, q1 := int64(0), int64(1), int64(1), int64(0)
+	remainder := x
+	for range _maxFracTerms {
+		a := math.Floor(remainder)
+		if q1 > 0 && a > float64((maxDen-q0)/q1) {
+			k := (maxDen - q0) / q1
+			pk, qk := p0+k*p1, q0+k*q1
+			if math.Abs(x-float64(pk)/float64(qk)) < math.Abs(x-float64(p1)/float64(q1)) {
+				return pk, qk
+			}
+			break
+		}
+		term := int64(a)
+		p0, q0, p1, q1 = p1, q1, term*p1+p0, term*q1+q0
+		if math.Abs(x-float64(p1)/float64(q1)) <= tolerance*math.Abs(x) {
+			break
+		}
+		frac := remainder - a
+		if frac == 0 {
+			break
+		}
+		remainder = 1 / frac
+	}
+	return p1, q1
+}
+
+func fractionFits(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0) && math.Abs(value) < _maxFracValue
+}
+
+func (d *Display) Fraction(value float64) string {
+	if !fractionFits(value) || value == math.Trunc(value) {
+		return fmt.Sprintf("%g", value)
+	}
+	sign := ""
+	if value < 0 {
+		sign = "-"
+	}
+	num, den := rationalApprox(math.Abs(value), d.maxDenominator, d.tolerance)
+	approx := ""
+	if float64(num)/float64(den) != math.Abs(value) {
+		approx = "≈"
+	}
+	if den == 1 {
+		return fmt.Sprintf("%s%s%d", approx, sign, num)
+	}
+	return fmt.Sprintf("%s%s%d/%d", approx, sign, num, den)
+}
+
+// Inch rounds to the nearest 1/inchDenominator and shows a reduced mixed
+// number such as 3 5/16.
+func (d *Display) Inch(value float64) string {
+	if !fractionFits(value) {
+		return fmt.Sprintf("%g", value)
+	}
+	den := d.inchDenominator
+	ticks := int64(math.Round(math.Abs(value) * float64(den)))
+	approx := ""
+	if float64(ticks)/float64(den) != math.Abs(value) {
+		approx = "≈"
+	}
+	sign := ""
+	if value < 0 && ticks != 0 {
+		sign = "-"
+	}
+	whole := ticks / den
+	num := ticks % den
+	divisor := int64(gcd(uint64(num), uint64(den)))
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s", approx, sign)
+	switch {
+	case num == 0:
+		fmt.Fprintf(&b, "%d", whole)
+	case whole == 0:
+		fmt.Fprintf(&b, "%d/%d", num/divisor, den/divisor)
+	default:
+		fmt.Fprintf(&b, "%d %d/%d", whole, num/divisor, den/divisor)
+	}
+	return b.String()
+}
+
+var (
+	showFracOp = Op{
+		"print stack.Top() as a fraction",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
+			fmt.Println(stack.display.Fraction(top))
+			return nil, nil
+		},
+	}
+
+	showInchOp = Op{
+		"print stack.Top() as a mixed number to the nearest inch fraction",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
+			fmt.Println(stack.display.Inch(top))
+			return nil, nil
+		},
+	}
+
+	dispFracOp = Op{
+		"display the stack as fractions",
+		func(stack *Stack) (Floats, error) {
+			stack.display.mode = DisplayFraction
+			return nil, nil
+		},
+	}
+
+	dispInchOp = Op{
+		"display the stack as mixed numbers to the nearest inch fraction, 1/64 by default",
+		func(stack *Stack) (Floats, error) {
+			stack.display.mode = DisplayInch
+			return nil, nil
+		},
+	}
+
+	dispStdOp = Op{
+		"display the stack using %g",
+		func(stack *Stack) (Floats, error) {
+			stack.display.mode = DisplayStandard
+			return nil, nil
+		},
+	}
+
+	maxDenOp = Op{
+		"set the largest denominator used for fractions from stack.Top()",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if top < 1 || top != math.Trunc(top) || top > _maxFracDenominator {
+				return nil, fmt.Errorf("denominator must be an integer from 1 to %g", _maxFracDenominator)
+			}
+			stack.display.maxDenominator = int64(top)
+			return nil, nil
+		},
+	}
+
+	fracTolOp = Op{
+		"set the relative tolerance used for fractions from stack.Top()",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if top < 0 || math.IsNaN(top) {
+				return nil, fmt.Errorf("tolerance must not be negative")
+			}
+			stack.display.tolerance = top
+			return nil, nil
+		},
+	}
+
+	inchDenOp = Op{
+		"set the inch fraction denominator from stack.Top(), e.g. 16 for 1/16 inch",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if top < 1 || top != math.Trunc(top) || top > _maxFracDenominator {
+				return nil, fmt.Errorf("denominator must be an integer from 1 to %g", _maxFracDenominator)
+			}
+			stack.display.inchDenominator = int64(top)
+			return nil, nil
+		},
+	}
+)
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFraction(t *testing.T) {
+	display := NewDisplay()
+	assert.Equal(t, "5/16", display.Fraction(0.3125))
+	assert.Equal(t, "≈355/113", display.Fraction(3.14159))
+	assert.Equal(t, "-3/4", display.Fraction(-0.75))
+	assert.Equal(t, "1/10", display.Fraction(0.1))
+	assert.Equal(t, "1/3", display.Fraction(1.0/3.0))
+	assert.Equal(t, "42", display.Fraction(42))
+	assert.Equal(t, "NaN", display.Fraction(math.NaN()))
+}
+
+func TestFractionLimits(t *testing.T) {
+	display := NewDisplay()
+	display.maxDenominator = 100
+	assert.Equal(t, "≈311/99", display.Fraction(3.14159265358979))
+	display.maxDenominator = 10
+	assert.Equal(t, "≈22/7", display.Fraction(3.14159265358979))
+	display.maxDenominator = _defaultMaxDenominator
+	display.tolerance = 1e-3
+	assert.Equal(t, "≈22/7", display.Fraction(3.14159265358979))
+}
+
+func TestInch(t *testing.T) {
+	display := NewDisplay()
+	assert.Equal(t, "3 5/16", display.Inch(3.3125))
+	assert.Equal(t, "≈3 5/16", display.Inch(3.31))
+	assert.Equal(t, "1/2", display.Inch(0.5))
+	assert.Equal(t, "-1 1/64", display.Inch(-1.015625))
+	assert.Equal(t, "2", display.Inch(2))
+	display.inchDenominator = 16
+	assert.Equal(t, "≈1 1/4", display.Inch(1.26))
+}
+
+func TestDisplayModes(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 0.3125, 3.3125)
+	assert.Equal(t, "[ 0.3125  3.3125 ]", stack.String())
+
+	assert.Nil(t, ops.Run("dfrac", stack))
+	assert.Equal(t, "[ 5/16  53/16 ]", stack.String())
+
+	assert.Nil(t, ops.Run("dinch", stack))
+	assert.Equal(t, "[ 5/16  3 5/16 ]", stack.String())
+
+	stack.Push(16)
+	assert.Nil(t, ops.Run("maxden", stack))
+	stack.Push(0)
+	assert.NotNil(t, ops.Run("inchden", stack))
+	assert.Equal(t, 0.0, stack.PopU())
+
+	assert.Nil(t, ops.Run("dstd", stack))
+	assert.Equal(t, "[ 0.3125  3.3125 ]", stack.String())
+	assert.Equal(t, "[ 0.312500  3.312500 ]", stack.StringF())
+}
@@ -0,0 +1,605 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Distribution ops follow MATLAB naming: xxxpdf, xxxcdf and xxxinv. The
+// variate or probability goes on the stack first, followed by the
+// distribution parameters, so "1.96 0 1 normcdf" reads left to right.
+
+const (
+	_distEpsilon  = 1e-15
+	_distTiny     = 1e-300
+	_distMaxIter  = 500
+	_bisectRounds = 200
+	// _maxExactInteger bounds discreteInv, past which float64 skips integers.
+	_maxExactInteger = 1 << 53
+)
+
+func lbeta(a, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	return la + lb - lab
+}
+
+func lchoose(n, k float64) float64 {
+	ln, _ := math.Lgamma(n + 1)
+	lk, _ := math.Lgamma(k + 1)
+	lnk, _ := math.Lgamma(n - k + 1)
+	return ln - lk - lnk
+}
+
+// maxIterations is the iteration limit for a series or continued fraction
+// whose parameters are about size. Near the mean they need a number of terms
+// that grows with the square root of the size.
+func maxIterations(size float64) float64 {
+	return _distMaxIter + 10*math.Sqrt(math.Abs(size))
+}
+
+// betaContinuedFraction evaluates the continued fraction for the incomplete
+// beta function by the modified Lentz method.
+func betaContinuedFraction(a, b, x float64) float64 {
+	clamp := func(v float64) float64 {
+		if math.Abs(v) < _distTiny {
+			return _distTiny
+		}
+		return v
+	}
+	qab := a + b
+	qap := a + 1
+	qam := a - 1
+	c := 1.0
+	d := 1 / clamp(1-qab*x/qap)
+	h := d
+	rounds := maxIterations(qab)
+	for m := 1.0; m <= rounds; m++ {
+		m2 := 2 * m
+		aa := m * (b - m) * x / ((qam + m2) * (a + m2))
+		d = 1 / clamp(1+aa*d)
+		c = clamp(1 + aa/c)
+		h *= d * c
+		aa = -(a + m) * (qab + m) * x / ((a + m2) * (qap + m2))
+		d = 1 / clamp(1+aa*d)
+		c = clamp(1 + aa/c)
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < _distEpsilon {
+			break
+		}
+	}
+	return h
+}
+
+// regIncBeta is the regularized incomplete beta function I_x(a, b).
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	front := math.Exp(a*math.Log(x) + b*math.Log1p(-x) - lbeta(a, b))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// regIncGamma is the regularized lower incomplete gamma function P(a, x).
+func regIncGamma(a, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lga, _ := math.Lgamma(a)
+	if x < a+1 {
+		ap := a
+		del := 1 / a
+		total := del
+		for range int(maxIterations(x)) {
+			ap++
+			del *= x / ap
+			total += del
+			if math.Abs(del) < math.Abs(total)*_distEpsilon {
+				break
+			}
+		}
+		return total * math.Exp(-x+a*math.Log(x)-lga)
+	}
+	b := x + 1 - a
+	c := 1 / _distTiny
+	d := 1 / b
+	h := d
+	rounds := maxIterations(x)
+	for i := 1.0; i <= rounds; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < _distTiny {
+			d = _distTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < _distTiny {
+			c = _distTiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < _distEpsilon {
+			break
+		}
+	}
+	return 1 - math.Exp(-x+a*math.Log(x)-lga)*h
+}
+
+// invertCDF finds x with cdf(x) = p by bisection, growing the bracket
+// [lo, hi] outward until it contains the answer. Bounds that are already
+// finite edges of the support are passed as fixed.
+func invertCDF(cdf func(float64) float64, p, lo, hi float64, fixedLo, fixedHi bool) float64 {
+	for !fixedLo && cdf(lo) > p {
+		lo = lo*2 - 1
+	}
+	for !fixedHi && cdf(hi) < p {
+		hi = hi*2 + 1
+	}
+	for range _bisectRounds {
+		mid := (lo + hi) / 2
+		if mid == lo || mid == hi {
+			break
+		}
+		if cdf(mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+func checkProbability(p float64) error {
+	if p < 0 || p > 1 || math.IsNaN(p) {
+		return fmt.Errorf("probability %g out of range [0, 1]", p)
+	}
+	return nil
+}
+
+func checkPositive(name string, v float64) error {
+	if v <= 0 || math.IsNaN(v) {
+		return fmt.Errorf("%s must be positive", name)
+	}
+	return nil
+}
+
+func checkCount(name string, v float64) error {
+	if v < 0 || v != math.Trunc(v) {
+		return fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return nil
+}
+
+func normPdf(x, mu, sigma float64) float64 {
+	z := (x - mu) / sigma
+	return math.Exp(-z*z/2) / (sigma * math.Sqrt(2*math.Pi))
+}
+
+func normCdf(x, mu, sigma float64) float64 {
+	return math.Erfc(-(x-mu)/(sigma*math.Sqrt2)) / 2
+}
+
+func normInv(p, mu, sigma float64) float64 {
+	return mu - sigma*math.Sqrt2*math.Erfcinv(2*p)
+}
+
+func tPdf(t, v float64) float64 {
+	return math.Exp(-lbeta(v/2, 0.5)-(v+1)/2*math.Log1p(t*t/v)) / math.Sqrt(v)
+}
+
+func tCdf(t, v float64) float64 {
+	tail := regIncBeta(v/2, 0.5, v/(v+t*t)) / 2
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+func chi2Pdf(x, k float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x == 0 {
+		switch {
+		case k < 2:
+			return math.Inf(1)
+		case k == 2:
+			return 0.5
+		default:
+			return 0
+		}
+	}
+	lg, _ := math.Lgamma(k / 2)
+	return math.Exp((k/2-1)*math.Log(x) - x/2 - k/2*math.Ln2 - lg)
+}
+
+func chi2Cdf(x, k float64) float64 {
+	return regIncGamma(k/2, x/2)
+}
+
+func fPdf(x, d1, d2 float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	logNum := (d1*math.Log(d1*x) + d2*math.Log(d2) - (d1+d2)*math.Log(d1*x+d2)) / 2
+	return math.Exp(logNum - math.Log(x) - lbeta(d1/2, d2/2))
+}
+
+func fCdf(x, d1, d2 float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return regIncBeta(d1/2, d2/2, d1*x/(d1*x+d2))
+}
+
+func binoPmf(k, n, p float64) float64 {
+	if k < 0 || k > n || k != math.Trunc(k) {
+		return 0
+	}
+	switch p {
+	case 0:
+		if k == 0 {
+			return 1
+		}
+		return 0
+	case 1:
+		if k == n {
+			return 1
+		}
+		return 0
+	}
+	return math.Exp(lchoose(n, k) + k*math.Log(p) + (n-k)*math.Log1p(-p))
+}
+
+func binoCdf(k, n, p float64) float64 {
+	k = math.Floor(k)
+	if k < 0 {
+		return 0
+	}
+	if k >= n {
+		return 1
+	}
+	return regIncBeta(n-k, k+1, 1-p)
+}
+
+func poissPmf(k, lambda float64) float64 {
+	if k < 0 || k != math.Trunc(k) {
+		return 0
+	}
+	lk, _ := math.Lgamma(k + 1)
+	return math.Exp(k*math.Log(lambda) - lambda - lk)
+}
+
+func poissCdf(k, lambda float64) float64 {
+	k = math.Floor(k)
+	if k < 0 {
+		return 0
+	}
+	return 1 - regIncGamma(k+1, lambda)
+}
+
+// discreteInv returns the smallest integer k with cdf(k) >= p, no more than
+// limit. It doubles k to bracket the answer and then bisects, so a large
+// mean costs a few dozen evaluations of cdf rather than one per integer.
+func discreteInv(cdf func(float64) float64, p, limit float64) float64 {
+	target := p * (1 - _distEpsilon)
+	if cdf(0) >= target {
+		return 0
+	}
+	low, high := 0.0, 1.0
+	for high < limit && high < _maxExactInteger && cdf(high) < target {
+		low, high = high, 2*high
+	}
+	high = min(high, limit)
+	for high-low > 1 {
+		middle := math.Floor((low + high) / 2)
+		if cdf(middle) < target {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return high
+}
+
+var (
+	normPdfOp = wrapDistOp("x μ σ normpdf, normal probability density", 3,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("σ", args[2]); err != nil {
+				return 0, err
+			}
+			return normPdf(args[0], args[1], args[2]), nil
+		})
+
+	normCdfOp = wrapDistOp("x μ σ normcdf, normal cumulative distribution", 3,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("σ", args[2]); err != nil {
+				return 0, err
+			}
+			return normCdf(args[0], args[1], args[2]), nil
+		})
+
+	normInvOp = wrapDistOp("p μ σ norminv, normal quantile", 3,
+		func(args []float64) (float64, error) {
+			if err := checkProbability(args[0]); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("σ", args[2]); err != nil {
+				return 0, err
+			}
+			return normInv(args[0], args[1], args[2]), nil
+		})
+
+	tPdfOp = wrapDistOp("t ν tpdf, Student t probability density", 2,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("ν", args[1]); err != nil {
+				return 0, err
+			}
+			return tPdf(args[0], args[1]), nil
+		})
+
+	tCdfOp = wrapDistOp("t ν tcdf, Student t cumulative distribution", 2,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("ν", args[1]); err != nil {
+				return 0, err
+			}
+			return tCdf(args[0], args[1]), nil
+		})
+
+	tInvOp = wrapDistOp("p ν tinv, Student t quantile", 2,
+		func(args []float64) (float64, error) {
+			p, v := args[0], args[1]
+			if err := checkProbability(p); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("ν", v); err != nil {
+				return 0, err
+			}
+			switch p {
+			case 0:
+				return math.Inf(-1), nil
+			case 1:
+				return math.Inf(1), nil
+			}
+			cdf := func(t float64) float64 { return tCdf(t, v) }
+			return invertCDF(cdf, p, -1, 1, false, false), nil
+		})
+
+	chi2PdfOp = wrapDistOp("x k chi2pdf, chi-square probability density", 2,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("k", args[1]); err != nil {
+				return 0, err
+			}
+			return chi2Pdf(args[0], args[1]), nil
+		})
+
+	chi2CdfOp = wrapDistOp("x k chi2cdf, chi-square cumulative distribution", 2,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("k", args[1]); err != nil {
+				return 0, err
+			}
+			return chi2Cdf(args[0], args[1]), nil
+		})
+
+	chi2InvOp = wrapDistOp("p k chi2inv, chi-square quantile", 2,
+		func(args []float64) (float64, error) {
+			p, k := args[0], args[1]
+			if err := checkProbability(p); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("k", k); err != nil {
+				return 0, err
+			}
+			if p == 1 {
+				return math.Inf(1), nil
+			}
+			cdf := func(x float64) float64 { return chi2Cdf(x, k) }
+			return invertCDF(cdf, p, 0, k+1, true, false), nil
+		})
+
+	fPdfOp = wrapDistOp("x d1 d2 fpdf, F probability density", 3,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("d1", args[1]); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("d2", args[2]); err != nil {
+				return 0, err
+			}
+			return fPdf(args[0], args[1], args[2]), nil
+		})
+
+	fCdfOp = wrapDistOp("x d1 d2 fcdf, F cumulative distribution", 3,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("d1", args[1]); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("d2", args[2]); err != nil {
+				return 0, err
+			}
+			return fCdf(args[0], args[1], args[2]), nil
+		})
+
+	fInvOp = wrapDistOp("p d1 d2 finv, F quantile", 3,
+		func(args []float64) (float64, error) {
+			p, d1, d2 := args[0], args[1], args[2]
+			if err := checkProbability(p); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("d1", d1); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("d2", d2); err != nil {
+				return 0, err
+			}
+			if p == 1 {
+				return math.Inf(1), nil
+			}
+			cdf := func(x float64) float64 { return fCdf(x, d1, d2) }
+			return invertCDF(cdf, p, 0, 2, true, false), nil
+		})
+
+	binoPdfOp = wrapDistOp("k n p binopdf, binomial probability mass", 3,
+		func(args []float64) (float64, error) {
+			if err := checkCount("n", args[1]); err != nil {
+				return 0, err
+			}
+			if err := checkProbability(args[2]); err != nil {
+				return 0, err
+			}
+			return binoPmf(args[0], args[1], args[2]), nil
+		})
+
+	binoCdfOp = wrapDistOp("k n p binocdf, binomial cumulative distribution", 3,
+		func(args []float64) (float64, error) {
+			if err := checkCount("n", args[1]); err != nil {
+				return 0, err
+			}
+			if err := checkProbability(args[2]); err != nil {
+				return 0, err
+			}
+			return binoCdf(args[0], args[1], args[2]), nil
+		})
+
+	binoInvOp = wrapDistOp("y n p binoinv, smallest k with binocdf(k) >= y", 3,
+		func(args []float64) (float64, error) {
+			y, n, p := args[0], args[1], args[2]
+			if err := checkProbability(y); err != nil {
+				return 0, err
+			}
+			if err := checkCount("n", n); err != nil {
+				return 0, err
+			}
+			if err := checkProbability(p); err != nil {
+				return 0, err
+			}
+			cdf := func(k float64) float64 { return binoCdf(k, n, p) }
+			return discreteInv(cdf, y, n), nil
+		})
+
+	poissPdfOp = wrapDistOp("k λ poisspdf, Poisson probability mass", 2,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("λ", args[1]); err != nil {
+				return 0, err
+			}
+			return poissPmf(args[0], args[1]), nil
+		})
+
+	poissCdfOp = wrapDistOp("k λ poisscdf, Poisson cumulative distribution", 2,
+		func(args []float64) (float64, error) {
+			if err := checkPositive("λ", args[1]); err != nil {
+				return 0, err
+			}
+			return poissCdf(args[0], args[1]), nil
+		})
+
+	poissInvOp = wrapDistOp("y λ poissinv, smallest k with poisscdf(k) >= y", 2,
+		func(args []float64) (float64, error) {
+			y, lambda := args[0], args[1]
+			if err := checkProbability(y); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("λ", lambda); err != nil {
+				return 0, err
+			}
+			if y == 1 {
+				return math.Inf(1), nil
+			}
+			cdf := func(k float64) float64 { return poissCdf(k, lambda) }
+			return discreteInv(cdf, y, math.Inf(1)), nil
+		})
+
+	expPdfOp = wrapDistOp("x λ exppdf, exponential probability density for rate λ", 2,
+		func(args []float64) (float64, error) {
+			x, lambda := args[0], args[1]
+			if err := checkPositive("λ", lambda); err != nil {
+				return 0, err
+			}
+			if x < 0 {
+				return 0, nil
+			}
+			return lambda * math.Exp(-lambda*x), nil
+		})
+
+	expCdfOp = wrapDistOp("x λ expcdf, exponential cumulative distribution for rate λ", 2,
+		func(args []float64) (float64, error) {
+			x, lambda := args[0], args[1]
+			if err := checkPositive("λ", lambda); err != nil {
+				return 0, err
+			}
+			if x < 0 {
+				return 0, nil
+			}
+			return -math.Expm1(-lambda * x), nil
+		})
+
+	expInvOp = wrapDistOp("p λ expinv, exponential quantile for rate λ", 2,
+		func(args []float64) (float64, error) {
+			p, lambda := args[0], args[1]
+			if err := checkProbability(p); err != nil {
+				return 0, err
+			}
+			if err := checkPositive("λ", lambda); err != nil {
+				return 0, err
+			}
+			return -math.Log1p(-p) / lambda, nil
+		})
+
+	unifPdfOp = wrapDistOp("x a b unifpdf, uniform probability density on [a, b]", 3,
+		func(args []float64) (float64, error) {
+			x, a, b := args[0], args[1], args[2]
+			if b <= a {
+				return 0, fmt.Errorf("uniform requires a < b")
+			}
+			if x < a || x > b {
+				return 0, nil
+			}
+			return 1 / (b - a), nil
+		})
+
+	unifCdfOp = wrapDistOp("x a b unifcdf, uniform cumulative distribution on [a, b]", 3,
+		func(args []float64) (float64, error) {
+			x, a, b := args[0], args[1], args[2]
+			if b <= a {
+				return 0, fmt.Errorf("uniform requires a < b")
+			}
+			return min(max((x-a)/(b-a), 0), 1), nil
+		})
+
+	unifInvOp = wrapDistOp("p a b unifinv, uniform quantile on [a, b]", 3,
+		func(args []float64) (float64, error) {
+			p, a, b := args[0], args[1], args[2]
+			if err := checkProbability(p); err != nil {
+				return 0, err
+			}
+			if b <= a {
+				return 0, fmt.Errorf("uniform requires a < b")
+			}
+			return a + p*(b-a), nil
+		})
+)
+
+func wrapDistOp(doc string, arity int, f func([]float64) (float64, error)) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			args, err := stack.PopR(arity)
+			if err != nil {
+				return nil, err
+			}
+			result, err := f(args)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Expected values are from standard statistical tables.
+func TestDistributionTables(t *testing.T) {
+	cases := []struct {
+		op       string
+		args     []float64
+		expected float64
+		delta    float64
+	}{
+		{"normcdf", []float64{1.96, 0, 1}, 0.9750021, 1e-7},
+		{"normcdf", []float64{110, 100, 15}, 0.7475075, 1e-7},
+		{"normpdf", []float64{0, 0, 1}, 0.3989423, 1e-7},
+		{"norminv", []float64{0.975, 0, 1}, 1.959964, 1e-6},
+		{"norminv", []float64{0.95, 0, 1}, 1.644854, 1e-6},
+		{"tinv", []float64{0.975, 10}, 2.228139, 1e-6},
+		{"tinv", []float64{0.995, 5}, 4.032143, 1e-6},
+		{"tinv", []float64{0.025, 10}, -2.228139, 1e-6},
+		{"tcdf", []float64{2.228139, 10}, 0.975, 1e-7},
+		{"tpdf", []float64{0, 1}, 0.3183099, 1e-7},
+		{"chi2inv", []float64{0.95, 1}, 3.841459, 1e-6},
+		{"chi2inv", []float64{0.95, 10}, 18.307038, 1e-6},
+		{"chi2inv", []float64{0.01, 5}, 0.5542981, 1e-6},
+		{"chi2cdf", []float64{3.841459, 1}, 0.95, 1e-7},
+		{"chi2pdf", []float64{2, 2}, 0.1839397, 1e-7},
+		{"finv", []float64{0.95, 5, 10}, 3.325835, 1e-6},
+		{"finv", []float64{0.99, 2, 20}, 5.848932, 1e-6},
+		{"fcdf", []float64{3.325835, 5, 10}, 0.95, 1e-7},
+		{"fpdf", []float64{1, 5, 10}, 0.4954798, 1e-7},
+		{"binopdf", []float64{5, 10, 0.5}, 0.2460938, 1e-7},
+		{"binocdf", []float64{3, 10, 0.5}, 0.171875, 1e-9},
+		{"binoinv", []float64{0.5, 10, 0.5}, 5, 0},
+		{"poisspdf", []float64{2, 3}, 0.2240418, 1e-7},
+		{"poisscdf", []float64{2, 3}, 0.4231901, 1e-7},
+		{"poissinv", []float64{0.5, 3}, 3, 0},
+		{"poissinv", []float64{0.5, 1e5}, 1e5, 0},
+		{"poissinv", []float64{0.975, 1e5}, 1e5 + 1.96*math.Sqrt(1e5), 1},
+		{"binoinv", []float64{0.5, 1e5, 0.5}, 5e4, 0},
+		{"binoinv", []float64{0.025, 1e5, 0.5}, 5e4 - 1.96*math.Sqrt(2.5e4), 1},
+		{"poissinv", []float64{0.5, 1e9}, 1e9, 0},
+		{"binoinv", []float64{0.5, 1e9, 0.5}, 5e8, 0},
+		{"exppdf", []float64{1, 2}, 0.2706706, 1e-7},
+		{"expcdf", []float64{1, 2}, 0.8646647, 1e-7},
+		{"expinv", []float64{0.5, 1}, 0.6931472, 1e-7},
+		{"unifpdf", []float64{3, 2, 6}, 0.25, 0},
+		{"unifcdf", []float64{3, 2, 6}, 0.25, 0},
+		{"unifinv", []float64{0.25, 2, 6}, 3, 0},
+	}
+	for _, c := range cases {
+		stack := NewStack()
+		ops := NewOps()
+		pushAll(stack, c.args...)
+		err := ops.Run(c.op, stack)
+		assert.Nil(t, err, c.op)
+		assert.Equal(t, 1, stack.Len(), c.op)
+		assert.InDelta(t, c.expected, topOf(t, stack), c.delta, "%s %v", c.op, c.args)
+	}
+}
+
+func TestDistributionInvalid(t *testing.T) {
+	cases := []struct {
+		op   string
+		args []float64
+	}{
+		{"normcdf", []float64{0, 0, 0}},
+		{"norminv", []float64{1.5, 0, 1}},
+		{"tinv", []float64{0.5, -1}},
+		{"binopdf", []float64{1, 2.5, 0.5}},
+		{"unifpdf", []float64{1, 2, 2}},
+		{"normpdf", []float64{0, 1}},
+	}
+	for _, c := range cases {
+		stack := NewStack()
+		ops := NewOps()
+		pushAll(stack, c.args...)
+		assert.NotNil(t, ops.Run(c.op, stack), c.op)
+	}
+}
@@ -1,16 +1,18 @@
 package main
 
 import (
-	"fmt"
 	"slices"
-	"strconv"
 
 	"github.com/expr-lang/expr"
 )
 
 func tryExpr(line string, stack *Stack) error {
-	localStack := stack.Copy()
-	slices.Reverse(localStack)
+	values := stack.Values()
+	slices.Reverse(values)
+	localStack := make([]any, 0, len(values))
+	for _, v := range values {
+		localStack = append(localStack, toAny(v))
+	}
 	env := map[string]any{
 		"s": localStack,
 	}
@@ -20,12 +22,11 @@ func tryExpr(line string, stack *Stack) error {
 		return err
 	}
 
-	outputStr := fmt.Sprintf("%v", output)
-	value, err := strconv.ParseFloat(outputStr, 64)
+	value, err := fromAny(output)
 	if err != nil {
 		return err
 	}
-	stack.Push(value)
+	stack.PushValue(value)
 
 	return nil
 }
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// FloatFormat describes an IEEE-754 style binary format by the widths of its
+// exponent and mantissa fields. The sign is always a single leading bit.
+type FloatFormat struct {
+	name     string
+	expBits  int
+	mantBits int
+}
+
+var (
+	_float64Format  = FloatFormat{"float64", 11, 52}
+	_float32Format  = FloatFormat{"float32", 8, 23}
+	_float16Format  = FloatFormat{"float16", 5, 10}
+	_bfloat16Format = FloatFormat{"bfloat16", 8, 7}
+)
+
+func (f FloatFormat) width() int {
+	return 1 + f.expBits + f.mantBits
+}
+
+func (f FloatFormat) bias() int {
+	return 1<<(f.expBits-1) - 1
+}
+
+func (f FloatFormat) maxExpField() uint64 {
+	return 1<<f.expBits - 1
+}
+
+// Encode rounds x to the nearest representable value, ties to even, and
+// returns its bit pattern.
+func (f FloatFormat) Encode(x float64) uint64 {
+	if f == _float64Format {
+		return math.Float64bits(x)
+	}
+	var sign uint64
+	if math.Signbit(x) {
+		sign = 1 << (f.width() - 1)
+	}
+	m := f.mantBits
+	switch {
+	case math.IsNaN(x):
+		return sign | f.maxExpField()<<m | 1<<(m-1)
+	case math.IsInf(x, 0):
+		return sign | f.maxExpField()<<m
+	case x == 0:
+		return sign
+	}
+	a := math.Abs(x)
+	bias := f.bias()
+	_, exp := math.Frexp(a)
+	e := exp - 1
+	if e < 1-bias {
+		// subnormal, a carry out of the mantissa lands in the exponent
+		// field and yields the smallest normal number
+		mant := uint64(math.RoundToEven(math.Ldexp(a, m+bias-1)))
+		return sign | mant
+	}
+	sig := math.RoundToEven(math.Ldexp(a, m-e))
+	if sig >= math.Ldexp(1, m+1) {
+		sig /= 2
+		e++
+	}
+	if e > bias {
+		return sign | f.maxExpField()<<m
+	}
+	mant := uint64(sig) - 1<<m
+	return sign | uint64(e+bias)<<m | mant
+}
+
+func (f FloatFormat) Decode(bits uint64) float64 {
+	if f == _float64Format {
+		return math.Float64frombits(bits)
+	}
+	m := f.mantBits
+	sign := 1.0
+	if bits>>(f.width()-1)&1 == 1 {
+		sign = -1.0
+	}
+	expField := bits >> m & f.maxExpField()
+	mant := bits & (1<<m - 1)
+	switch expField {
+	case f.maxExpField():
+		if mant != 0 {
+			return math.NaN()
+		}
+		return math.Inf(int(sign))
+	case 0:
+		return sign * math.Ldexp(float64(mant), 1-f.bias()-m)
+	}
+	return sign * math.Ldexp(float64(mant|1<<m), int(expField)-f.bias()-m)
+}
+
+func (f FloatFormat) Describe(x float64) string {
+	bits := f.Encode(x)
+	m := f.mantBits
+	sign := bits >> (f.width() - 1) & 1
+	expField := bits >> m & f.maxExpField()
+	mant := bits & (1<<m - 1)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %g\n", f.name, f.Decode(bits))
+	fmt.Fprintf(&b, "  sign     %d\n", sign)
+	fmt.Fprintf(&b, "  exponent %0*b", f.expBits, expField)
+	switch expField {
+	case f.maxExpField():
+		if mant != 0 {
+			fmt.Fprintf(&b, " (NaN)\n")
+		} else {
+			fmt.Fprintf(&b, " (infinity)\n")
+		}
+	case 0:
+		fmt.Fprintf(&b, " (subnormal, 2^%d)\n", 1-f.bias())
+	default:
+		fmt.Fprintf(&b, " (%d, 2^%d)\n", expField, int(expField)-f.bias())
+	}
+	fmt.Fprintf(&b, "  mantissa %0*b\n", m, mant)
+	fmt.Fprintf(&b, "  hex      0x%0*X", f.width()/4, bits)
+	return b.String()
+}
+
+// orderedBits maps a float64 onto an integer line where adjacent floats are
+// adjacent integers, so that subtraction counts ulps.
+func orderedBits(x float64) int64 {
+	bits := int64(math.Float64bits(x))
+	if bits < 0 {
+		return math.MinInt64 - bits
+	}
+	return bits
+}
+
+func ulpDistance(x, y float64) (float64, error) {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return 0, fmt.Errorf("ulp distance to NaN is undefined")
+	}
+	a, b := orderedBits(x), orderedBits(y)
+	if a > b {
+		a, b = b, a
+	}
+	return float64(uint64(b - a)), nil
+}
+
+var (
+	ieee64Op   = wrapDescribeOp(_float64Format)
+	ieee32Op   = wrapDescribeOp(_float32Format)
+	ieee16Op   = wrapDescribeOp(_float16Format)
+	ieeeBf16Op = wrapDescribeOp(_bfloat16Format)
+
+	f32ToBitsOp  = wrapToBitsOp(_float32Format)
+	f16ToBitsOp  = wrapToBitsOp(_float16Format)
+	bf16ToBitsOp = wrapToBitsOp(_bfloat16Format)
+
+	bitsToF32Op  = wrapFromBitsOp(_float32Format)
+	bitsToF16Op  = wrapFromBitsOp(_float16Format)
+	bitsToBf16Op = wrapFromBitsOp(_bfloat16Format)
+
+	// float64 bit patterns do not fit in a float64 mantissa, so they travel
+	// as two 32-bit words
+	f64ToBitsOp = Op{
+		"replace stack.Top() with its float64 bit pattern as hi and lo 32-bit words, lo on top",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			bits := math.Float64bits(top)
+			return Floats{float64(bits & math.MaxUint32), float64(bits >> 32)}, nil
+		},
+	}
+
+	bitsToF64Op = Op{
+		"hi lo bits>f64, build a float64 from two 32-bit words",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			hi, err := checkBits(elems[0], 32)
+			if err != nil {
+				return nil, err
+			}
+			lo, err := checkBits(elems[1], 32)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{math.Float64frombits(hi<<32 | lo)}, nil
+		},
+	}
+
+	ulpOp = Op{
+		"x y ulp, number of float64 steps between x and y",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			result, err := ulpDistance(elems[0], elems[1])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+)
+
+func checkBits(value float64, width int) (uint64, error) {
+	if value < 0 || value != math.Trunc(value) || value >= math.Ldexp(1, width) {
+		return 0, fmt.Errorf("%g is not a %d-bit pattern", value, width)
+	}
+	return uint64(value), nil
+}
+
+func wrapDescribeOp(format FloatFormat) Op {
+	return Op{
+		fmt.Sprintf("print the sign, exponent and mantissa of stack.Top() as %s", format.name),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
+			fmt.Println(format.Describe(top))
+			return nil, nil
+		},
+	}
+}
+
+func wrapToBitsOp(format FloatFormat) Op {
+	return Op{
+		fmt.Sprintf("replace stack.Top() with its %s bit pattern", format.name),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			bits := format.Encode(top)
+			fmt.Printf("0x%0*X\n", format.width()/4, bits)
+			return Floats{float64(bits)}, nil
+		},
+	}
+}
+
+func wrapFromBitsOp(format FloatFormat) Op {
+	return Op{
+		fmt.Sprintf("replace a %d-bit pattern on stack.Top() with the %s it encodes", format.width(), format.name),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			bits, err := checkBits(top, format.width())
+			if err != nil {
+				return nil, err
+			}
+			return Floats{format.Decode(bits)}, nil
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloat32MatchesStdlib(t *testing.T) {
+	values := []float64{
+		0, 1, -2.5, 0.1, 1e-40, 1e-46, 3.4e38, 3.5e38, 16777217, math.Inf(-1),
+		math.SmallestNonzeroFloat32, math.MaxFloat32, 1.0000000596046448,
+	}
+	for _, v := range values {
+		assert.Equal(t, uint64(math.Float32bits(float32(v))), _float32Format.Encode(v), "%g", v)
+		assert.Equal(t, float64(float32(v)), _float32Format.Decode(_float32Format.Encode(v)), "%g", v)
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	cases := []struct {
+		value float64
+		bits  uint64
+	}{
+		{1, 0x3C00},
+		{-2, 0xC000},
+		{65504, 0x7BFF},
+		{65520, 0x7C00},
+		{0.1, 0x2E66},
+		{0.333251953125, 0x3555},
+		{math.Ldexp(1, -24), 0x0001},
+		{math.Ldexp(1, -14), 0x0400},
+		{math.Ldexp(1, -26), 0x0000},
+		{math.Inf(1), 0x7C00},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.bits, _float16Format.Encode(c.value), "%g", c.value)
+	}
+	assert.Equal(t, 65504.0, _float16Format.Decode(0x7BFF))
+	assert.Equal(t, math.Ldexp(1, -24), _float16Format.Decode(0x0001))
+	assert.True(t, math.IsNaN(_float16Format.Decode(_float16Format.Encode(math.NaN()))))
+}
+
+func TestBfloat16(t *testing.T) {
+	assert.Equal(t, uint64(0x3F80), _bfloat16Format.Encode(1))
+	assert.Equal(t, uint64(0x4049), _bfloat16Format.Encode(math.Pi))
+	assert.Equal(t, 3.140625, _bfloat16Format.Decode(0x4049))
+}
+
+func TestBitsOps(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(1)
+	assert.Nil(t, ops.Run("f64>bits", stack))
+	assert.Equal(t, []float64{0x3FF00000, 0}, scalars(t, stack))
+	assert.Nil(t, ops.Run("bits>f64", stack))
+	assert.Equal(t, []float64{1}, scalars(t, stack))
+
+	stack.Clear()
+	stack.Push(0x4049)
+	assert.Nil(t, ops.Run("bits>bf16", stack))
+	assert.Equal(t, 3.140625, topOf(t, stack))
+	assert.Nil(t, ops.Run("f16>bits", stack))
+	assert.Equal(t, float64(0x4248), topOf(t, stack))
+
+	stack.Push(0x10000)
+	assert.NotNil(t, ops.Run("bits>f16", stack))
+}
+
+func TestUlp(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, math.Nextafter(math.Nextafter(1, 2), 2))
+	assert.Nil(t, ops.Run("ulp", stack))
+	assert.Equal(t, 2.0, stack.PopU())
+
+	pushAll(stack, -math.SmallestNonzeroFloat64, math.SmallestNonzeroFloat64)
+	assert.Nil(t, ops.Run("ulp", stack))
+	assert.Equal(t, 2.0, stack.PopU())
+
+	pushAll(stack, 0.1, 0.1)
+	assert.Nil(t, ops.Run("ulp", stack))
+	assert.Equal(t, 0.0, stack.PopU())
+}
+
+func TestDescribe(t *testing.T) {
+	expected := "float16 1\n" +
+		"  sign     0\n" +
+		"  exponent 01111 (15, 2^0)\n" +
+		"  mantissa 0000000000\n" +
+		"  hex      0x3C00"
+	assert.Equal(t, expected, _float16Format.Describe(1))
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	_singularTolerance = 1e-12
+)
+
+func asMatrix(v Value) (Matrix, error) {
+	switch t := v.(type) {
+	case Matrix:
+		return t, nil
+	case Vector:
+		return Matrix{1, len(t), t}, nil
+	}
+	return Matrix{}, fmt.Errorf("expected a matrix but found %s", describeKind(v))
+}
+
+func asVector(v Value) (Vector, error) {
+	vector, ok := v.(Vector)
+	if !ok {
+		return nil, fmt.Errorf("expected a vector but found %s", describeKind(v))
+	}
+	return vector, nil
+}
+
+func dot(x, y Vector) (float64, error) {
+	if len(x) != len(y) {
+		return 0, fmt.Errorf("cannot dot a %d-vector with a %d-vector", len(x), len(y))
+	}
+	result := 0.0
+	for i := range x {
+		result += x[i] * y[i]
+	}
+	return result, nil
+}
+
+func cross(x, y Vector) (Vector, error) {
+	if len(x) != 3 || len(y) != 3 {
+		return nil, fmt.Errorf("cross product needs two 3-vectors")
+	}
+	return Vector{
+		x[1]*y[2] - x[2]*y[1],
+		x[2]*y[0] - x[0]*y[2],
+		x[0]*y[1] - x[1]*y[0],
+	}, nil
+}
+
+func transpose(m Matrix) Matrix {
+	result := NewMatrix(m.cols, m.rows)
+	for i := range m.rows {
+		for j := range m.cols {
+			result.Set(j, i, m.At(i, j))
+		}
+	}
+	return result
+}
+
+// matmul multiplies matrices. A vector on the left acts as a row and a vector
+// on the right as a column, and the result is a vector again in that case.
+func matmul(x, y Value) (Value, error) {
+	if xv, ok := x.(Vector); ok {
+		if yv, ok := y.(Vector); ok {
+			result, err := dot(xv, yv)
+			return Scalar(result), err
+		}
+	}
+	a, err := asMatrix(x)
+	if err != nil {
+		return nil, err
+	}
+	_, yIsVector := y.(Vector)
+	b, err := asMatrix(y)
+	if err != nil {
+		return nil, err
+	}
+	if yIsVector {
+		b = transpose(b)
+	}
+	if a.cols != b.rows {
+		return nil, fmt.Errorf("cannot multiply %s by %s", describeKind(x), describeKind(y))
+	}
+	result := NewMatrix(a.rows, b.cols)
+	for i := range a.rows {
+		for j := range b.cols {
+			total := 0.0
+			for k := range a.cols {
+				total += a.At(i, k) * b.At(k, j)
+			}
+			result.Set(i, j, total)
+		}
+	}
+	_, xIsVector := x.(Vector)
+	if xIsVector || yIsVector {
+		return Vector(result.data), nil
+	}
+	return result, nil
+}
+
+// luDecompose factors a square matrix in place with partial pivoting and
+// returns the row permutation and its sign.
+func luDecompose(m Matrix) (Matrix, []int, float64, error) {
+	if m.rows != m.cols {
+		return Matrix{}, nil, 0, fmt.Errorf("expected a square matrix but found %s", describeKind(m))
+	}
+	n := m.rows
+	lu := m.clone()
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	sign := 1.0
+	scale := 0.0
+	for _, x := range m.data {
+		scale = max(scale, math.Abs(x))
+	}
+	for k := range n {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(lu.At(i, k)) > math.Abs(lu.At(pivot, k)) {
+				pivot = i
+			}
+		}
+		if math.Abs(lu.At(pivot, k)) <= _singularTolerance*scale {
+			return lu, perm, 0, fmt.Errorf("matrix is singular")
+		}
+		if pivot != k {
+			for j := range n {
+				a, b := lu.At(k, j), lu.At(pivot, j)
+				lu.Set(k, j, b)
+				lu.Set(pivot, j, a)
+			}
+			perm[k], perm[pivot] = perm[pivot], perm[k]
+			sign = -sign
+		}
+		for i := k + 1; i < n; i++ {
+			factor := lu.At(i, k) / lu.At(k, k)
+			lu.Set(i, k, factor)
+			for j := k + 1; j < n; j++ {
+				lu.Set(i, j, lu.At(i, j)-factor*lu.At(k, j))
+			}
+		}
+	}
+	return lu, perm, sign, nil
+}
+
+func determinant(m Matrix) (float64, error) {
+	lu, _, sign, err := luDecompose(m)
+	if err != nil {
+		if m.rows == m.cols {
+			return 0, nil
+		}
+		return 0, err
+	}
+	result := sign
+	for i := range m.rows {
+		result *= lu.At(i, i)
+	}
+	return result, nil
+}
+
+func luSolve(lu Matrix, perm []int, b Vector) Vector {
+	n := lu.rows
+	x := make(Vector, n)
+	for i := range n {
+		total := b[perm[i]]
+		for j := range i {
+			total -= lu.At(i, j) * x[j]
+		}
+		x[i] = total
+	}
+	for i := n - 1; i >= 0; i-- {
+		total := x[i]
+		for j := i + 1; j < n; j++ {
+			total -= lu.At(i, j) * x[j]
+		}
+		x[i] = total / lu.At(i, i)
+	}
+	return x
+}
+
+func solveLinear(a Matrix, b Vector) (Vector, error) {
+	if a.rows != len(b) {
+		return nil, fmt.Errorf("cannot solve %s system for %s", describeKind(a), describeKind(b))
+	}
+	lu, perm, _, err := luDecompose(a)
+	if err != nil {
+		return nil, err
+	}
+	return luSolve(lu, perm, b), nil
+}
+
+// solveSystem solves A b popped as values, for the linear form of solve.
+func solveSystem(stack *Stack, elems []Value) (Floats, error) {
+	a, err := asMatrix(elems[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := asVector(elems[1])
+	if err != nil {
+		return nil, err
+	}
+	result, err := solveLinear(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return pushResult(stack, result)
+}
+
+func inverse(m Matrix) (Matrix, error) {
+	lu, perm, _, err := luDecompose(m)
+	if err != nil {
+		return Matrix{}, err
+	}
+	n := m.rows
+	result := NewMatrix(n, n)
+	for j := range n {
+		unit := make(Vector, n)
+		unit[j] = 1
+		column := luSolve(lu, perm, unit)
+		for i := range n {
+			result.Set(i, j, column[i])
+		}
+	}
+	return result, nil
+}
+
+var (
+	dotOp = Op{
+		"dot product of two vectors",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopValues(2)
+			if err != nil {
+				return nil, err
+			}
+			x, err := asVector(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			y, err := asVector(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			result, err := dot(x, y)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	crossOp = Op{
+		"cross product of two 3-vectors",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopValues(2)
+			if err != nil {
+				return nil, err
+			}
+			x, err := asVector(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			y, err := asVector(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			result, err := cross(x, y)
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, result)
+		},
+	}
+
+	normOp = Op{
+		"Euclidean norm of a vector, Frobenius norm of a matrix",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			if elements(top) == nil {
+				return nil, fmt.Errorf("expected a vector or matrix but found %s", describeKind(top))
+			}
+			total := 0.0
+			for _, x := range elements(top) {
+				total += x * x
+			}
+			return Floats{math.Sqrt(total)}, nil
+		},
+	}
+
+	transposeOp = Op{
+		"transpose a matrix, a vector becomes a column matrix",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			m, err := asMatrix(top)
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, transpose(m))
+		},
+	}
+
+	detOp = Op{
+		"determinant of a square matrix",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			m, err := asMatrix(top)
+			if err != nil {
+				return nil, err
+			}
+			result, err := determinant(m)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	invOp = Op{
+		"inverse of a square matrix",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			m, err := asMatrix(top)
+			if err != nil {
+				return nil, err
+			}
+			result, err := inverse(m)
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, result)
+		},
+	}
+
+	matmulOp = Op{
+		"matrix product of the second and top values",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopValues(2)
+			if err != nil {
+				return nil, err
+			}
+			result, err := matmul(elems[0], elems[1])
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, result)
+		},
+	}
+
+	toVectorOp = Op{
+		"n >vec, gather the top n scalars into a vector",
+		func(stack *Stack) (Floats, error) {
+			window, err := popWindow(stack)
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, Vector(window))
+		},
+	}
+
+	fromVectorOp = Op{
+		"spread the vector or matrix on top of the stack into scalars",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			values := elements(top)
+			if values == nil {
+				return nil, fmt.Errorf("expected a vector or matrix but found %s", describeKind(top))
+			}
+			result := make(Floats, len(values))
+			for i, x := range values {
+				result[len(values)-1-i] = x
+			}
+			return result, nil
+		},
+	}
+)
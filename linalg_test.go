@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pushLiteral(t *testing.T, stack *Stack, literal string) {
+	err := tryArray(literal, stack)
+	assert.Nil(t, err, literal)
+}
+
+func TestArrayLiterals(t *testing.T) {
+	stack := NewStack()
+	pushLiteral(t, stack, "[1 2 3]")
+	pushLiteral(t, stack, "[[1 2][3 4]]")
+	pushLiteral(t, stack, "[[1 -2.5] [3e2 4]]")
+	assert.Equal(t, "[ [1 2 3]  [[1 2][3 4]]  [[1 -2.5][300 4]] ]", stack.String())
+
+	assert.NotNil(t, tryArray("[]", stack))
+	assert.NotNil(t, tryArray("[[1 2][3]]", stack))
+	assert.NotNil(t, tryArray("[1 x]", stack))
+}
+
+func TestVectorOps(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[1 2 3]")
+	pushLiteral(t, stack, "[4 5 6]")
+	assert.Nil(t, ops.Run("dot", stack))
+	assert.Equal(t, 32.0, stack.PopU())
+
+	pushLiteral(t, stack, "[1 0 0]")
+	pushLiteral(t, stack, "[0 1 0]")
+	assert.Nil(t, ops.Run("cross", stack))
+	assert.Equal(t, "[ [0 0 1] ]", stack.String())
+
+	stack.Clear()
+	pushLiteral(t, stack, "[3 4]")
+	assert.Nil(t, ops.Run("norm", stack))
+	assert.Equal(t, 5.0, stack.PopU())
+}
+
+func TestBroadcasting(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[1 4 9]")
+	assert.Nil(t, ops.Run("sqrt", stack))
+	assert.Equal(t, "[ [1 2 3] ]", stack.String())
+
+	stack.Push(10)
+	assert.Nil(t, ops.Run("*", stack))
+	assert.Equal(t, "[ [10 20 30] ]", stack.String())
+
+	pushLiteral(t, stack, "[1 2 3]")
+	assert.Nil(t, ops.Run("-", stack))
+	assert.Equal(t, "[ [9 18 27] ]", stack.String())
+
+	pushLiteral(t, stack, "[1 2]")
+	assert.NotNil(t, ops.Run("+", stack))
+	assert.Equal(t, 2, stack.Len())
+
+	stack.Clear()
+	pushLiteral(t, stack, "[[1 2][3 4]]")
+	assert.Nil(t, ops.Run("neg", stack))
+	assert.Equal(t, "[ [[-1 -2][-3 -4]] ]", stack.String())
+}
+
+func TestMatrixOps(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[[1 2][3 4]]")
+	assert.Nil(t, ops.Run("det", stack))
+	assertClose(t, -2, stack.PopU())
+
+	pushLiteral(t, stack, "[[1 2][3 4]]")
+	assert.Nil(t, ops.Run("inv", stack))
+	inverse, err := stack.TopValue()
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{-2, 1, 1.5, -0.5}, elements(inverse), 1e-9)
+
+	pushLiteral(t, stack, "[[1 2][3 4]]")
+	assert.Nil(t, ops.Run("@", stack))
+	product, err := stack.PopValue()
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{1, 0, 0, 1}, elements(product), 1e-9)
+
+	stack.Clear()
+	pushLiteral(t, stack, "[[1 2 3][4 5 6]]")
+	assert.Nil(t, ops.Run("transpose", stack))
+	assert.Equal(t, "[ [[1 4][2 5][3 6]] ]", stack.String())
+	pushLiteral(t, stack, "[1 1]")
+	assert.Nil(t, ops.Run("mmul", stack))
+	assert.Equal(t, "[ [5 7 9] ]", stack.String())
+
+	stack.Clear()
+	pushLiteral(t, stack, "[[1 2][2 4]]")
+	assert.Nil(t, ops.Run("det", stack))
+	assert.Equal(t, 0.0, stack.PopU())
+	pushLiteral(t, stack, "[[1 2][2 4]]")
+	assert.NotNil(t, ops.Run("inv", stack))
+}
+
+func TestSolve(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[[2 1 -1][-3 -1 2][-2 1 2]]")
+	pushLiteral(t, stack, "[8 -11 -3]")
+	assert.Nil(t, ops.Run("solve", stack))
+	assert.Nil(t, ops.Run("vec>", stack))
+	results, err := stack.PopR(3)
+	assert.Nil(t, err)
+	assertClose(t, 2, results[0])
+	assertClose(t, 3, results[1])
+	assertClose(t, -1, results[2])
+}
+
+func TestStatsOverVector(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(100)
+	pushLiteral(t, stack, "[1 2 3 4]")
+	assert.Nil(t, ops.Run("avg", stack))
+	assert.Equal(t, 2.5, stack.PopU())
+	assert.Nil(t, ops.Run("median", stack))
+	assert.Equal(t, 2.5, stack.PopU())
+	assert.Equal(t, 2, stack.Len())
+
+	stack.Push(5)
+	assert.NotNil(t, ops.Run("sum", stack))
+}
+
+func TestVectorConversions(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 3, 3)
+	assert.Nil(t, ops.Run(">vec", stack))
+	assert.Equal(t, "[ [1 2 3] ]", stack.String())
+	assert.Nil(t, ops.Run("vec>", stack))
+	assert.Equal(t, []float64{1, 2, 3}, scalars(t, stack))
+}
+
+func TestExprWithVectors(t *testing.T) {
+	stack := NewStack()
+	pushLiteral(t, stack, "[1 2 3]")
+	stack.Push(2)
+	assert.Nil(t, tryExpr("s[1][2] * s[0]", stack))
+	assert.Equal(t, 6.0, topOf(t, stack))
+	assert.Nil(t, tryExpr("map(s[2], # * 2)", stack))
+	assert.Equal(t, "[ [1 2 3]  2  6  [2 4 6] ]", stack.String())
+}
+
+func TestStackHoldsValues(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(1)
+	pushLiteral(t, stack, "[1 2]")
+	_, err := stack.Pop()
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, stack.Len())
+	assert.Nil(t, ops.Run("swap", stack))
+	assert.Equal(t, 1.0, topOf(t, stack))
+	assert.Nil(t, ops.Run("pop", stack))
+	assert.Nil(t, ops.Run("pop", stack))
+	assert.True(t, stack.Empty())
+}
@@ -12,23 +12,12 @@ func main() {
 
 	stack := NewStack()
 
-	lastLine := ""
 	for {
 		shell.SetPrompt(stack.String() + "> ")
 		line := shell.ReadLine()
-		if len(line) <= 0 {
-			line = lastLine
-		}
 		err := cascade(line, stack, ops)
 		if err != nil {
-			if len(lastLine) > 0 {
-				err = cascade(lastLine, stack, ops)
-				if err != nil {
-					fmt.Println(err)
-				}
-				continue
-			}
+			fmt.Println(err)
 		}
-		lastLine = line
 	}
 }
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// Integer ops compute with math/big so that results are exact. A result that
+// a float64 holds exactly is pushed as a number, and one that it does not is
+// pushed as a whole Decimal, which keeps and shows all its digits. Results
+// past the float64 range are +Inf, and are not computed.
+
+const (
+	_maxExactFactorial = 170
+	_maxFibonacci      = 1476
+	_maxFloatBits      = 1024
+)
+
+func toBigInt(f float64) (*big.Int, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+		return nil, fmt.Errorf("%g is not an integer", f)
+	}
+	result, _ := big.NewFloat(f).Int(nil)
+	return result, nil
+}
+
+func toUint64(f float64) (uint64, error) {
+	if f < 0 || f >= math.MaxUint64 || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%g is not a non-negative integer", f)
+	}
+	return uint64(f), nil
+}
+
+// pushExact pushes n as a number when a float64 holds it exactly, as a whole
+// Decimal when it does not, and as an infinity past the float64 range.
+func pushExact(stack *Stack, n *big.Int) {
+	f, accuracy := new(big.Float).SetInt(n).Float64()
+	if accuracy == big.Exact || math.IsInf(f, 0) {
+		stack.Push(f)
+		return
+	}
+	stack.PushValue(Decimal{new(big.Rat).SetInt(n)})
+}
+
+func exactFactorial(n int64) *big.Int {
+	return new(big.Int).MulRange(1, n)
+}
+
+// exactPermutations is n!/(n-r)!, or false once the product is past the
+// float64 range, which bounds the work by the size of the result.
+func exactPermutations(n, r uint64) (*big.Int, bool) {
+	result := big.NewInt(1)
+	if r > n {
+		return big.NewInt(0), true
+	}
+	for k := range r {
+		result.Mul(result, new(big.Int).SetUint64(n-k))
+		if result.BitLen() > _maxFloatBits {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+// exactCombinations builds n choose r one factor at a time, each partial
+// result C(n, k) a whole number, and gives up the same way.
+func exactCombinations(n, r uint64) (*big.Int, bool) {
+	if r > n {
+		return big.NewInt(0), true
+	}
+	r = min(r, n-r)
+	result := big.NewInt(1)
+	for k := range r {
+		result.Mul(result, new(big.Int).SetUint64(n-k))
+		result.Quo(result, new(big.Int).SetUint64(k+1))
+		if result.BitLen() > _maxFloatBits {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+// fibonacci uses the fast doubling identities
+// F(2k) = F(k)(2F(k+1) - F(k)) and F(2k+1) = F(k)^2 + F(k+1)^2.
+func fibonacci(n uint64) *big.Int {
+	a := big.NewInt(0)
+	b := big.NewInt(1)
+	for i := bits.Len64(n) - 1; i >= 0; i-- {
+		twoB := new(big.Int).Lsh(b, 1)
+		c := new(big.Int).Mul(a, twoB.Sub(twoB, a))
+		d := new(big.Int).Mul(a, a)
+		d.Add(d, new(big.Int).Mul(b, b))
+		if (n>>uint(i))&1 == 0 {
+			a, b = c, d
+		} else {
+			a, b = d, c.Add(c, d)
+		}
+	}
+	return a
+}
+
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+func isPrime(n uint64) bool {
+	return new(big.Int).SetUint64(n).ProbablyPrime(20)
+}
+
+// pollardRho finds a non-trivial factor of the composite n using Brent's
+// variant of Pollard's rho.
+func pollardRho(n uint64) uint64 {
+	if n%2 == 0 {
+		return 2
+	}
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 {
+			return (mulMod(x, x, n) + c) % n
+		}
+		x, y, d := uint64(2), uint64(2), uint64(1)
+		for d == 1 {
+			x = f(x)
+			y = f(f(y))
+			diff := x - y
+			if x < y {
+				diff = y - x
+			}
+			d = gcd(diff, n)
+		}
+		if d != n {
+			return d
+		}
+	}
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func primeFactors(n uint64) []uint64 {
+	factors := []uint64{}
+	for _, p := range []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37} {
+		for n%p == 0 {
+			factors = append(factors, p)
+			n /= p
+		}
+	}
+	var split func(uint64)
+	split = func(m uint64) {
+		if m == 1 {
+			return
+		}
+		if isPrime(m) {
+			factors = append(factors, m)
+			return
+		}
+		d := pollardRho(m)
+		split(d)
+		split(m / d)
+	}
+	split(n)
+	return factors
+}
+
+func nextPrime(n uint64) (uint64, error) {
+	for candidate := n + 1; candidate > n; candidate++ {
+		if isPrime(candidate) {
+			return candidate, nil
+		}
+	}
+	return 0, fmt.Errorf("no prime after %d fits in 64 bits", n)
+}
+
+var (
+	exactFactorialOp = Op{
+		"exact factorial of a non-negative integer, gamma based for anything else",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(top)
+			if err != nil || n > _maxExactFactorial {
+				return factorialOp.f(stack)
+			}
+			_ = stack.PopU()
+			pushExact(stack, exactFactorial(int64(n)))
+			return nil, nil
+		},
+	}
+
+	nCrOp = Op{
+		"n r ncr, exact number of combinations of r items from n",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			r, err := toUint64(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			result, ok := exactCombinations(n, r)
+			if !ok {
+				return Floats{math.Inf(1)}, nil
+			}
+			pushExact(stack, result)
+			return nil, nil
+		},
+	}
+
+	nPrOp = Op{
+		"n r npr, exact number of permutations of r items from n",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			r, err := toUint64(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			result, ok := exactPermutations(n, r)
+			if !ok {
+				return Floats{math.Inf(1)}, nil
+			}
+			pushExact(stack, result)
+			return nil, nil
+		},
+	}
+
+	gcdOp = Op{
+		"greatest common divisor of the top two integers",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			a, err := toBigInt(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			b, err := toBigInt(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			result := new(big.Int).GCD(nil, nil, a.Abs(a), b.Abs(b))
+			pushExact(stack, result)
+			return nil, nil
+		},
+	}
+
+	lcmOp = Op{
+		"least common multiple of the top two integers",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			a, err := toBigInt(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			b, err := toBigInt(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			a.Abs(a)
+			b.Abs(b)
+			if a.Sign() == 0 || b.Sign() == 0 {
+				return Floats{0}, nil
+			}
+			divisor := new(big.Int).GCD(nil, nil, a, b)
+			result := new(big.Int).Mul(a, b)
+			result.Quo(result, divisor)
+			pushExact(stack, result)
+			return nil, nil
+		},
+	}
+
+	isPrimeOp = Op{
+		"1 if stack.Top() is prime (Miller-Rabin), 0 otherwise",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(top)
+			if err != nil {
+				return nil, err
+			}
+			if isPrime(n) {
+				return Floats{1}, nil
+			}
+			return Floats{0}, nil
+		},
+	}
+
+	factorOp = Op{
+		"replace stack.Top() with its prime factors, largest on top",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(top)
+			if err != nil {
+				return nil, err
+			}
+			if n < 2 {
+				return Floats{top}, nil
+			}
+			factors := primeFactors(n)
+			result := make(Floats, 0, len(factors))
+			for i := len(factors) - 1; i >= 0; i-- {
+				result = append(result, float64(factors[i]))
+			}
+			return result, nil
+		},
+	}
+
+	nextPrimeOp = Op{
+		"smallest prime greater than stack.Top()",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(math.Max(math.Floor(top), 0))
+			if err != nil {
+				return nil, err
+			}
+			result, err := nextPrime(n)
+			if err != nil {
+				return nil, err
+			}
+			pushExact(stack, new(big.Int).SetUint64(result))
+			return nil, nil
+		},
+	}
+
+	powModOp = Op{
+		"b e m powmod, b^e mod m computed exactly",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			args := make([]*big.Int, 0, len(elems))
+			for _, elem := range elems {
+				arg, err := toBigInt(elem)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+			if args[2].Sign() <= 0 {
+				return nil, fmt.Errorf("modulus must be positive")
+			}
+			if args[1].Sign() < 0 {
+				inverse := new(big.Int).ModInverse(args[0], args[2])
+				if inverse == nil {
+					return nil, fmt.Errorf("%s has no inverse mod %s", args[0], args[2])
+				}
+				args[0] = inverse
+				args[1].Neg(args[1])
+			}
+			result := new(big.Int).Exp(args[0], args[1], args[2])
+			pushExact(stack, result)
+			return nil, nil
+		},
+	}
+
+	modInvOp = Op{
+		"a m modinv, the inverse of a modulo m",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			a, err := toBigInt(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			m, err := toBigInt(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			if m.Sign() <= 0 {
+				return nil, fmt.Errorf("modulus must be positive")
+			}
+			result := new(big.Int).ModInverse(a, m)
+			if result == nil {
+				return nil, fmt.Errorf("%s has no inverse mod %s", a, m)
+			}
+			pushExact(stack, result)
+			return nil, nil
+		},
+	}
+
+	fibOp = Op{
+		"exact nth Fibonacci number",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			n, err := toUint64(top)
+			if err != nil {
+				return nil, err
+			}
+			if n > _maxFibonacci {
+				return Floats{math.Inf(1)}, nil
+			}
+			pushExact(stack, fibonacci(n))
+			return nil, nil
+		},
+	}
+)
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExactFactorial(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(25)
+	err := ops.Run("!", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, 15511210043330985984000000.0, topOf(t, stack))
+	assert.Equal(t, "15511210043330985984000000", exactFactorial(25).String())
+
+	assert.Equal(t, "[ 15511210043330985984000000 ]", stack.String())
+	runAll(t, stack, ops, "1", "+")
+	assert.Equal(t, "[ 15511210043330985984000001 ]", stack.String())
+	stack.Clear()
+
+	stack.Push(0)
+	err = ops.Run("!", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.0, topOf(t, stack))
+
+	stack.Push(171)
+	err = ops.Run("!", stack)
+	assert.Nil(t, err)
+	assert.True(t, math.IsInf(topOf(t, stack), 1))
+}
+
+func TestExactResultsPastFloatRange(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	for _, line := range [][]string{
+		{"1477", "fib"},
+		{"1e6", "fib"},
+		{"100000", "50000", "ncr"},
+		{"1e18", "1e9", "npr"},
+	} {
+		runAll(t, stack, ops, line...)
+		assert.True(t, math.IsInf(stack.PopU(), 1), "%v", line)
+	}
+
+	runAll(t, stack, ops, "1476", "fib")
+	assert.False(t, math.IsInf(stack.PopU(), 0))
+	runAll(t, stack, ops, "1e15", "2", "ncr")
+	assert.Equal(t, "[ 499999999999999500000000000000 ]", stack.String())
+}
+
+func TestGammaFactorial(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(0.5)
+	err := ops.Run("!", stack)
+	assert.Nil(t, err)
+	assertClose(t, 0.886226925452758, topOf(t, stack))
+
+	stack.Push(5)
+	err = ops.Run("gfact", stack)
+	assert.Nil(t, err)
+	assertClose(t, 120, topOf(t, stack))
+}
+
+func TestCombinatorics(t *testing.T) {
+	cases := []struct {
+		op       string
+		args     []float64
+		expected float64
+	}{
+		{"ncr", []float64{52, 5}, 2598960},
+		{"nCr", []float64{5, 7}, 0},
+		{"npr", []float64{10, 3}, 720},
+		{"gcd", []float64{462, 1071}, 21},
+		{"gcd", []float64{-12, 18}, 6},
+		{"lcm", []float64{4, 6}, 12},
+		{"lcm", []float64{0, 6}, 0},
+		{"isprime", []float64{2147483647}, 1},
+		{"isprime", []float64{561}, 0},
+		{"isprime", []float64{1}, 0},
+		{"nextprime", []float64{100}, 101},
+		{"nextprime", []float64{7}, 11},
+		{"powmod", []float64{4, 13, 497}, 445},
+		{"powmod", []float64{3, -1, 11}, 4},
+		{"modinv", []float64{3, 11}, 4},
+		{"fib", []float64{0}, 0},
+		{"fib", []float64{10}, 55},
+		{"fib", []float64{78}, 8944394323791464},
+	}
+	for _, c := range cases {
+		stack := NewStack()
+		ops := NewOps()
+		pushAll(stack, c.args...)
+		err := ops.Run(c.op, stack)
+		assert.Nil(t, err, c.op)
+		assert.Equal(t, c.expected, topOf(t, stack), "%s %v", c.op, c.args)
+	}
+}
+
+func TestFactor(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(360)
+	err := ops.Run("factor", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{2, 2, 2, 3, 3, 5}, scalars(t, stack))
+
+	stack.Clear()
+	stack.Push(600851475143)
+	err = ops.Run("factor", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{71, 839, 1471, 6857}, scalars(t, stack))
+
+	stack.Clear()
+	stack.Push(999985999949)
+	err = ops.Run("factor", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{999983, 1000003}, scalars(t, stack))
+}
+
+func TestIntegerOpsReject(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 2.5, 3)
+	assert.NotNil(t, ops.Run("gcd", stack))
+	pushAll(stack, 2, 4)
+	assert.NotNil(t, ops.Run("modinv", stack))
+	stack.Push(-3)
+	assert.NotNil(t, ops.Run("isprime", stack))
+}
+
+func TestFibonacciBig(t *testing.T) {
+	assert.Equal(t, "354224848179261915075", fibonacci(100).String())
+}
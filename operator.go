@@ -1,15 +1,12 @@
 package main
 
 import (
-	"crypto/rand"
 	"fmt"
 	"math"
-	"math/big"
 	"os"
 	"slices"
 	"strings"
-
-	"github.com/eclesh/welford"
+	"unicode/utf8"
 )
 
 type (
@@ -32,8 +29,10 @@ type (
 func (o *Ops) Run(line string, stack *Stack) error {
 	op, ok := o.opmap[line]
 	if ok {
+		saved := stack.Snapshot()
 		results, err := op.f(stack)
 		if err != nil {
+			stack.Restore(saved)
 			return err
 		}
 		for i := len(results) - 1; i >= 0; i-- {
@@ -47,8 +46,11 @@ func (o *Ops) Run(line string, stack *Stack) error {
 func NewOps() *Ops {
 	ops := Ops{
 		opmap: OpMap{
-			"!":           factorialOp,
-			"%":           wrapBinaryOp("floating-point remainder of x/y", math.Mod),
+			"!":           exactFactorialOp,
+			"%":           percentOp,
+			"%ch":         percentChangeOp,
+			"%of":         percentOfOp,
+			"%t":          percentTotalOp,
 			"*":           mulOp,
 			"**":          wrapBinaryOp("x^y, the base-x exponential of y", math.Pow),
 			"+":           plusOp,
@@ -58,26 +60,80 @@ func NewOps() *Ops {
 			"/":           divOp,
 			"<<":          leftShiftOp,
 			">>":          rightShiftOp,
+			">epoch":      toEpochOp,
+			">dhms":       showDurationOp,
+			">frac":       showFracOp,
+			">inch":       showInchOp,
+			">sec":        toSecondsOp,
+			">vec":        toVectorOp,
+			"@":           matmulOp,
 			"^":           wrapBinaryOp("x^y, the base-x exponential of y", math.Pow),
 			"abs":         wrapUnaryOp("absolute value", math.Abs),
+			"abv":         abvOp,
 			"acos":        wrapUnaryOp("arccosine, in radians", math.Acos),
 			"acosh":       wrapUnaryOp("inverse hyperbolic cosine", math.Acosh),
+			"amort":       amortOp,
+			"arange":      arangeOp,
 			"asin":        wrapUnaryOp("arcsine", math.Asin),
 			"asinh":       wrapUnaryOp("inverse hyperbolic sine ", math.Asinh),
 			"atan":        wrapUnaryOp("arctangent", math.Atan),
 			"atan2":       wrapBinaryOp("tangent of y/x", math.Atan2),
 			"avg":         avgOp,
-			"c":           wrapConstant("speed of light in m/s", 299792458),
+			"bal":         ballisticShowOp,
+			"balt":        ballisticAltitudeOp,
+			"bc":          ballisticBCOp,
+			"bdays":       businessDaysOp,
+			"beg":         tvmBeginOp,
+			"bf16>bits":   bf16ToBitsOp,
+			"binocdf":     binoCdfOp,
+			"binoinv":     binoInvOp,
+			"binopdf":     binoPdfOp,
+			"bits>bf16":   bitsToBf16Op,
+			"bits>f16":    bitsToF16Op,
+			"bits>f32":    bitsToF32Op,
+			"bits>f64":    bitsToF64Op,
+			"brix>plato":  brixToPlatoOp,
+			"brix>sg":     brixToSGOp,
+			"btemp":       ballisticTempOp,
+			"c":           wrapConstant("speed of light in m/s", _c),
+			"cabs":        cabsOp,
+			"card":        rangeCardOp,
+			"carg":        cargOp,
+			"chi2cdf":     chi2CdfOp,
+			"chi2inv":     chi2InvOp,
+			"chi2pdf":     chi2PdfOp,
 			"cl":          clearOp,
+			"clbal":       ballisticClearOp,
+			"clfin":       tvmClearOp,
 			"clr":         clearOp,
 			"clear":       clearOp,
 			"cbrt":        wrapUnaryOp("cube root", math.Cbrt),
 			"ceil":        wrapUnaryOp("least integer value greater than or equal to stack.Top()", math.Ceil),
 			"cf":          cfOp,
+			"const":       constOp,
+			"corr":        sigmaCorrOp,
 			"cos":         wrapUnaryOp("cosine", math.Cos),
 			"cosh":        wrapUnaryOp("hyperbolic cosine", math.Cosh),
+			"cross":       crossOp,
+			"dalt":        densityAltitudeOp,
+			"dalth":       densityAltitudeHPaOp,
+			"days":        daysOp,
+			"dec":         decimalOnOp,
+			"deriv":       derivOp,
+			"det":         detOp,
+			"dfrac":       dispFracOp,
 			"dim":         wrapBinaryOp("maximum of x-y or 0", math.Dim),
+			"dinch":       dispInchOp,
+			"doppler":     dopplerOp,
+			"dot":         dotOp,
+			"dow":         dowOp,
+			"drhalfeven":  decimalHalfEvenOp,
+			"drhalfup":    decimalHalfUpOp,
+			"dstd":        dispStdOp,
+			"dup":         dupOp,
 			"e":           wrapConstant("euler's constant", math.E),
+			"end":         tvmEndOp,
+			"epoch>":      fromEpochOp,
 			"erf":         wrapUnaryOp("error function", math.Erf),
 			"erfc":        wrapUnaryOp("complementary error function", math.Erfc),
 			"erfcinv":     wrapUnaryOp("inverse of erfc", math.Erfcinv),
@@ -85,30 +141,91 @@ func NewOps() *Ops {
 			"exit":        qOp,
 			"exp":         wrapUnaryOp("e^x, the base-e exponential", math.Exp),
 			"exp2":        wrapUnaryOp("2^x, the base-2 exponential", math.Exp2),
+			"expcdf":      expCdfOp,
+			"expinv":      expInvOp,
 			"expm1":       wrapUnaryOp("e^x - 1, the base-e exponential of x minus 1. It is more accurate than exp - 1 when x is near zero", math.Expm1),
+			"exppdf":      expPdfOp,
 			"f":           fOp,
+			"f(x)":        showFunctionOp,
+			"f16>bits":    f16ToBitsOp,
+			"f32>bits":    f32ToBitsOp,
+			"f64>bits":    f64ToBitsOp,
+			"factor":      factorOp,
 			"fc":          fcOp,
+			"fcdf":        fCdfOp,
+			"fib":         fibOp,
+			"fincents":    tvmCentsOp,
+			"finexact":    tvmExactOp,
+			"finv":        fInvOp,
 			"fj":          fjOp,
 			"floor":       wrapUnaryOp("greatest integer value less than or equal to stack.Top()", math.Floor),
 			"fm":          fmOp,
+			"fv":          tvmFVOp,
+			"fv?":         tvmSolveFVOp,
+			"fx":          fxOp,
 			"fma":         wrapTernaryOp("fused multiply-add of x, y, and z", math.FMA),
+			"fold":        foldOp,
+			"fpdf":        fPdfOp,
+			"fractol":     fracTolOp,
 			"frexp":       frexpOp,
+			"fromq":       fromQOp,
+			"fromq15":     fromQ15Op,
+			"fromq31":     fromQ31Op,
+			"g1":          ballisticG1Op,
+			"g7":          ballisticG7Op,
 			"gamma":       wrapUnaryOp("gamma function ", math.Gamma),
+			"gcd":         gcdOp,
+			"gfact":       factorialOp,
 			"gl":          glOp,
+			"gmean":       gmeanOp,
+			"hmean":       hmeanOp,
+			"hold":        holdOp,
 			"hw":          hwOp,
+			"hydro":       hydrometerOp,
 			"hypot":       wrapBinaryOp("sqrt(p*p + q*q), taking care to avoid unnecessary overflow and underflow", math.Hypot),
+			"ibu":         ibuOp,
+			"ibum":        ibumOp,
+			"icept":       sigmaIcptOp,
+			"ieee":        ieee64Op,
+			"ieee16":      ieee16Op,
+			"ieee32":      ieee32Op,
+			"ieeebf16":    ieeeBf16Op,
 			"ilogb":       ilogbOp,
+			"im":          imagOp,
+			"inchden":     inchDenOp,
 			"inf":         wrapConstant("positive infinity", math.Inf(1)),
+			"integrate":   integrateOp,
+			"inv":         invOp,
+			"iota":        iotaOp,
+			"iqr":         iqrOp,
+			"irr":         irrOp,
+			"isa":         isaOp,
+			"isaa":        isaSoundOp,
+			"isaam":       isaSoundMOp,
+			"isamu":       isaViscosityOp,
+			"isamum":      isaViscosityMOp,
+			"isap":        isaPressureOp,
+			"isapm":       isaPressureMOp,
+			"isarho":      isaDensityOp,
+			"isarhom":     isaDensityMOp,
+			"isat":        isaTempOp,
+			"isatm":       isaTempMOp,
 			"isinf":       isInfOp,
 			"isnan":       isNanOp,
 			"isninf":      isNInfOp,
+			"isoweek":     isoWeekOp,
+			"isprime":     isPrimeOp,
 			"j0":          wrapUnaryOp("order-zero Bessel function of the first kind", math.J0),
 			"j1":          wrapUnaryOp("order-one Bessel function of the first kind", math.J1),
 			"jf":          jfOp,
 			"jn":          jnOp,
+			"ke":          kineticEnergyOp,
 			"kp":          kpOp,
+			"lcm":         lcmOp,
+			"lcon":        lengthContractionOp,
 			"lg":          lgOp,
 			"lgamma":      lgammaOp,
+			"linspace":    linspaceOp,
 			"ln2":         wrapConstant("natural log of 2", math.Ln2),
 			"ln10":        wrapConstant("natural log of 10", math.Ln10),
 			"log2e":       wrapConstant("1 / ln2", math.Log2E),
@@ -119,56 +236,190 @@ func NewOps() *Ops {
 			"log2":        wrapUnaryOp("binary logarithm", math.Log2),
 			"logb":        wrapUnaryOp("binary exponent", math.Logb),
 			"lor":         lorOp,
+			"mad":         madOp,
+			"map":         mapOp,
+			"margin":      marginOp,
+			"markup":      markupOp,
 			"max":         maxOp,
+			"maxden":      maxDenOp,
+			"median":      medianOp,
 			"mf":          mfOp,
 			"mil":         milOp,
+			"mils":        ballisticMilsOp,
 			"min":         minOp,
+			"mmul":        matmulOp,
+			"moa":         ballisticMOAOp,
 			"mod":         wrapBinaryOp("floating-point remainder of x/y", math.Mod),
+			"mode":        modeOp,
 			"modf":        modfOp,
+			"modinv":      modInvOp,
 			"mph":         mphOp,
+			"mv":          ballisticVelocityOp,
+			"mx":          sigmaMeanXOp,
+			"my":          sigmaMeanYOp,
+			"nCr":         nCrOp,
+			"nPr":         nPrOp,
 			"nan":         wrapConstant("not a number", math.NaN()),
+			"navg":        navgOp,
+			"ncr":         nCrOp,
 			"neg":         negOp,
 			"nextafter":   wrapBinaryOp("next representable float64 value after x towards y", math.Nextafter),
+			"nextprime":   nextPrimeOp,
+			"ngmean":      ngmeanOp,
+			"nhmean":      nhmeanOp,
 			"ninf":        wrapConstant("negative infinity", math.Inf(-1)),
+			"niqr":        niqrOp,
+			"nmad":        nmadOp,
+			"nmax":        nmaxOp,
+			"nmedian":     nmedianOp,
+			"nmin":        nminOp,
+			"nmode":       nmodeOp,
+			"nodec":       decimalOffOp,
 			"noop":        noOp,
+			"norm":        normOp,
+			"normcdf":     normCdfOp,
+			"norminv":     normInvOp,
+			"normpdf":     normPdfOp,
+			"nouconst":    exactConstantsOp,
+			"now":         nowOp,
+			"npct":        npctOp,
+			"npr":         nPrOp,
+			"npv":         npvOp,
+			"nquartiles":  nquartilesOp,
+			"nrange":      nrangeOp,
+			"norep":       repeatOffOp,
+			"nsd":         nsdOp,
+			"nsort":       nsortOp,
+			"nsum":        nsumOp,
+			"nvar":        nvarOp,
 			"p":           pOp,
+			"palt":        pressureAltitudeOp,
+			"palth":       pressureAltitudeHPaOp,
 			"pas":         pasOp,
+			"pct":         pctOp,
 			"phi":         wrapConstant("golden ratio", math.Phi),
 			"pi":          wrapConstant("ratio of a circle's circumference to its diameter", math.Pi),
 			"pk":          pkOp,
+			"plato>brix":  platoToBrixOp,
+			"plato>sg":    platoToSGOp,
+			"pm":          plusMinusOp,
+			"pmt":         tvmPMTOp,
+			"pmt?":        tvmSolvePMTOp,
+			"poisscdf":    poissCdfOp,
+			"poissinv":    poissInvOp,
+			"poisspdf":    poissPdfOp,
+			"poly":        polyOp,
+			"polyfit":     polyfitOp,
 			"pop":         pOp,
 			"pow":         wrapBinaryOp("x^y, the base-x exponential of y", math.Pow),
 			"pow10":       pow10Op,
+			"powmod":      powModOp,
+			"ppy":         tvmPeriodsOp,
 			"pr":          prOp,
+			"primesugar":  primingSugarOp,
+			"pu":          pasteurizationUnitsOp,
+			"pv":          tvmPVOp,
+			"pv?":         tvmSolvePVOp,
 			"q":           qOp,
+			"q15":         toQ15Op,
+			"q31":         toQ31Op,
+			"qreven":      qRoundEvenOp,
+			"qrfloor":     qRoundFloorOp,
+			"qrnear":      qRoundNearestOp,
+			"qrzero":      qRoundZeroOp,
+			"qsat":        qSaturateOp,
+			"qtype":       qtypeOp,
+			"quartiles":   quartilesOp,
+			"qwrap":       qWrapOp,
 			"r":           randOp,
+			"rand01":      rand01Op,
+			"randexp":     randExpOp,
+			"randnorm":    randNormOp,
+			"randpoiss":   randPoissOp,
+			"range":       rangeOp,
+			"rapid":       rapidityOp,
+			"re":          realOp,
 			"remainder":   wrapBinaryOp("IEEE 754 floating-point remainder of x/y", math.Remainder),
+			"rep":         repeatOnOp,
 			"rn":          randNOp,
+			"roots":       rootsOp,
 			"round":       wrapUnaryOp("returns the nearest integer, rounding half away from zero", math.Round),
 			"roundtoeven": wrapUnaryOp("returns the nearest integer, rounding ties to even", math.RoundToEven),
+			"s+":          sigmaAddOp,
+			"s-":          sigmaRemoveOp,
+			"scale":       decimalScaleOp,
+			"sclr":        sigmaClearOp,
 			"sd":          sdOp,
+			"sdx":         sigmaSdXOp,
+			"sdy":         sigmaSdYOp,
+			"sec>":        fromSecondsOp,
+			"seed":        seedOp,
+			"sg>brix":     sgToBrixOp,
+			"sg>plato":    sgToPlatoOp,
+			"shuffle":     shuffleOp,
+			"sight":       ballisticSightOp,
 			"signbit":     signbitOp,
 			"sin":         wrapUnaryOp("sine", math.Sin),
 			"sincos":      sincosOp,
 			"sinh":        wrapUnaryOp("hyperbolic sine", math.Sinh),
+			"slope":       sigmaSlopeOp,
+			"smean":       sigmaMeanOp,
+			"sn":          sigmaNOp,
+			"solve":       solveOp,
 			"sort":        sortOp,
 			"sqrt":        wrapUnaryOp("square root", math.Sqrt),
 			"sqrt2":       wrapConstant("square root of 2", math.Sqrt2),
 			"sqrte":       wrapConstant("square root of e", math.SqrtE),
 			"sqrtphi":     wrapConstant("square root of the golden ratio", math.SqrtPhi),
 			"sqrtpi":      wrapConstant("square root of pi", math.SqrtPi),
+			"strike":      strikeOp,
 			"sum":         sumOp,
 			"sw":          swapOp,
 			"swa":         swapOp,
 			"swap":        swapOp,
 			"tan":         wrapUnaryOp("tangent", math.Tan),
 			"tanh":        wrapUnaryOp("hyperbolic tangent", math.Tanh),
+			"tcdf":        tCdfOp,
+			"tdil":        timeDilationOp,
+			"tinv":        tInvOp,
+			"today":       todayOp,
+			"toq":         toQOp,
+			"tpdf":        tPdfOp,
+			"transpose":   transposeOp,
 			"trunc":       wrapUnaryOp("integer value of stack.Top()", math.Trunc),
+			"tvm":         tvmShowOp,
+			"tvmi":        tvmIOp,
+			"tvmi?":       tvmSolveIOp,
+			"tvmn":        tvmNOp,
+			"tvmn?":       tvmSolveNOp,
+			"tz":          tzOp,
+			"uconst":      uncertainConstantsOp,
+			"ulp":         ulpOp,
+			"unc":         splitUncertainOp,
+			"undo":        undoOp,
+			"unifcdf":     unifCdfOp,
+			"unifinv":     unifInvOp,
+			"unifpdf":     unifPdfOp,
+			"unseed":      unseedOp,
+			"uparen":      conciseDisplayOp,
+			"upm":         plusMinusDisplayOp,
+			"vadd":        velocityAdditionOp,
 			"var":         varOp,
+			"vec>":        fromVectorOp,
 			"wh":          whOp,
+			"wind":        ballisticWindOp,
+			"xhat":        sigmaXHatOp,
+			"x̂":          sigmaXHatOp,
 			"y0":          wrapUnaryOp("order-zero Bessel function of the second kind", math.Y0),
 			"y1":          wrapUnaryOp("order-one Bessel function of the second kind", math.Y1),
+			"yhat":        sigmaYHatOp,
 			"yn":          ynOp,
+			"zero":        ballisticZeroOp,
+			"zip":         zipOp,
+			"zone":        zoneOp,
+			"ŷ":           sigmaYHatOp,
+			"Σ+":          sigmaAddOp,
+			"Σ-":          sigmaRemoveOp,
 		},
 	}
 	return &ops
@@ -186,27 +437,9 @@ var (
 		},
 	}
 
-	mulOp = Op{
-		"multiplication",
-		func(stack *Stack) (Floats, error) {
-			elems, err := stack.PopR(2)
-			if err != nil {
-				return nil, err
-			}
-			return Floats{elems[0] * elems[1]}, nil
-		},
-	}
+	mulOp = wrapHookedArithOp("multiplication", func(x, y float64) float64 { return x * y }, decimalHook(decimalMul), durationMul, uncertainHook(uncertainMul))
 
-	plusOp = Op{
-		"addition",
-		func(stack *Stack) (Floats, error) {
-			elems, err := stack.PopR(2)
-			if err != nil {
-				return nil, err
-			}
-			return Floats{elems[0] + elems[1]}, nil
-		},
-	}
+	plusOp = wrapHookedArithOp("addition", func(x, y float64) float64 { return x + y }, decimalHook(decimalAdd), timeAdd, uncertainHook(uncertainAdd))
 
 	incrOp = Op{
 		"increment",
@@ -219,16 +452,7 @@ var (
 		},
 	}
 
-	minusOp = Op{
-		"subtraction",
-		func(stack *Stack) (Floats, error) {
-			elems, err := stack.PopR(2)
-			if err != nil {
-				return nil, err
-			}
-			return Floats{elems[0] - elems[1]}, nil
-		},
-	}
+	minusOp = wrapHookedArithOp("subtraction", func(x, y float64) float64 { return x - y }, decimalHook(decimalSub), timeSub, uncertainHook(uncertainSub))
 
 	decrOp = Op{
 		"decrement",
@@ -242,17 +466,19 @@ var (
 		},
 	}
 
-	divOp = Op{
-		"division",
+	dupOp = Op{
+		"duplicate the top element",
 		func(stack *Stack) (Floats, error) {
-			elems, err := stack.PopR(2)
+			err := stack.Dup()
 			if err != nil {
 				return nil, err
 			}
-			return Floats{elems[0] / elems[1]}, nil
+			return nil, nil
 		},
 	}
 
+	divOp = wrapHookedArithOp("division", func(x, y float64) float64 { return x / y }, decimalHook(decimalDiv), durationDiv, uncertainHook(uncertainDiv))
+
 	leftShiftOp = Op{
 		"left shift",
 		func(stack *Stack) (Floats, error) {
@@ -275,17 +501,7 @@ var (
 		},
 	}
 
-	avgOp = Op{
-		"average (mean) of the entire stack",
-		func(stack *Stack) (Floats, error) {
-			stats := welford.New()
-			arr := stack.Copy()
-			for _, n := range arr {
-				stats.Add(n)
-			}
-			return Floats{stats.Mean()}, nil
-		},
-	}
+	avgOp = wrapAggregateOp("average (mean) of the entire stack", mean)
 
 	cfOp = Op{
 		"celcius to fahrenheit conversion",
@@ -396,7 +612,10 @@ var (
 	isInfOp = Op{
 		"1 if stack.Top() is +Inf",
 		func(stack *Stack) (Floats, error) {
-			top := stack.Top()
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
 			if math.IsInf(top, 1) {
 				return Floats{1}, nil
 			}
@@ -407,7 +626,10 @@ var (
 	isNanOp = Op{
 		"1 if stack.Top() is NaN",
 		func(stack *Stack) (Floats, error) {
-			top := stack.Top()
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
 			if math.IsNaN(top) {
 				return Floats{1}, nil
 			}
@@ -418,7 +640,10 @@ var (
 	isNInfOp = Op{
 		"1 if stack.Top() is -Inf",
 		func(stack *Stack) (Floats, error) {
-			top := stack.Top()
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
 			if math.IsInf(top, -1) {
 				return Floats{1}, nil
 			}
@@ -491,22 +716,12 @@ var (
 				return nil, err
 			}
 			topSq := top * top
-			cSq := 89875517873681764.0
+			cSq := _c * _c
 			return Floats{1.0 / math.Sqrt(1-topSq/cSq)}, nil
 		},
 	}
 
-	maxOp = Op{
-		"find the maximum value of the entire stack",
-		func(stack *Stack) (Floats, error) {
-			stats := welford.New()
-			arr := stack.Copy()
-			for _, n := range arr {
-				stats.Add(n)
-			}
-			return Floats{stats.Max()}, nil
-		},
-	}
+	maxOp = wrapAggregateOp("find the maximum value of the entire stack", maximum)
 
 	mfOp = Op{
 		"meters to feet conversion",
@@ -534,17 +749,7 @@ var (
 		},
 	}
 
-	minOp = Op{
-		"find the minimum value of the entire stack",
-		func(stack *Stack) (Floats, error) {
-			stats := welford.New()
-			arr := stack.Copy()
-			for _, n := range arr {
-				stats.Add(n)
-			}
-			return Floats{stats.Min()}, nil
-		},
-	}
+	minOp = wrapAggregateOp("find the minimum value of the entire stack", minimum)
 
 	modfOp = Op{
 		"integer and fractional floating-point numbers that sum to f",
@@ -586,7 +791,7 @@ var (
 	pOp = Op{
 		"pop an item from the stack",
 		func(stack *Stack) (Floats, error) {
-			_, _ = stack.Pop()
+			_, _ = stack.PopValue()
 			return nil, nil
 		},
 	}
@@ -598,7 +803,7 @@ var (
 			if err != nil {
 				return nil, err
 			}
-			return Floats{math.Exp(top*-0.231) * 1.23e15 * 60}, nil
+			return Floats{pasteurization(top)}, nil
 		},
 	}
 
@@ -626,13 +831,17 @@ var (
 	}
 
 	prOp = Op{
-		"pressure in inHg for a given altitude in feet",
+		"standard atmosphere pressure in inHg for a given altitude in feet",
 		func(stack *Stack) (Floats, error) {
 			top, err := stack.Pop()
 			if err != nil {
 				return nil, err
 			}
-			return Floats{29.9212524 * math.Pow(1-math.Pow(10, -5)*2.25577*(top/3.280839895), 5.25588)}, nil
+			a, err := isa(top / _ftPerM)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{a.pressure / _paPerInHg}, nil
 		},
 	}
 
@@ -647,11 +856,7 @@ var (
 	randOp = Op{
 		fmt.Sprintf("random number from 0 to %d", _defaultMaxRand),
 		func(stack *Stack) (Floats, error) {
-			result, err := rand.Int(rand.Reader, big.NewInt(int64(_defaultMaxRand)))
-			if err != nil {
-				return nil, err
-			}
-			return Floats{float64(result.Int64())}, nil
+			return Floats{float64(stack.rng.Int64N(_defaultMaxRand))}, nil
 		},
 	}
 
@@ -662,18 +867,20 @@ var (
 			if err != nil {
 				return nil, err
 			}
-			result, err := rand.Int(rand.Reader, big.NewInt(int64(top)))
-			if err != nil {
-				return nil, err
+			if int64(top) < 1 {
+				return nil, fmt.Errorf("random bound must be at least 1")
 			}
-			return Floats{float64(result.Int64())}, nil
+			return Floats{float64(stack.rng.Int64N(int64(top)))}, nil
 		},
 	}
 
 	signbitOp = Op{
 		"the sign bit of the number, 1 means negative, 0 positive",
 		func(stack *Stack) (Floats, error) {
-			top := stack.Top()
+			top, err := stack.Top()
+			if err != nil {
+				return nil, err
+			}
 			if math.Signbit(top) {
 				return Floats{1.0}, nil
 			}
@@ -681,20 +888,7 @@ var (
 		},
 	}
 
-	sdOp = Op{
-		"standard deviation of the entire stack",
-		func(stack *Stack) (Floats, error) {
-			if stack.Len() <= 1 {
-				return Floats{0}, nil
-			}
-			stats := welford.New()
-			arr := stack.Copy()
-			for _, n := range arr {
-				stats.Add(n)
-			}
-			return Floats{stats.Stddev()}, nil
-		},
-	}
+	sdOp = wrapAggregateOp("standard deviation of the entire stack", stddev)
 
 	sincosOp = Op{
 		"returns sin and cos",
@@ -711,22 +905,15 @@ var (
 	sortOp = Op{
 		"sort the entire stack",
 		func(stack *Stack) (Floats, error) {
-			stack.Sort()
+			err := stack.Sort()
+			if err != nil {
+				return nil, err
+			}
 			return nil, nil
 		},
 	}
 
-	sumOp = Op{
-		"sum the entire stack",
-		func(stack *Stack) (Floats, error) {
-			arr := stack.Copy()
-			result := 0.0
-			for _, n := range arr {
-				result += n
-			}
-			return Floats{result}, nil
-		},
-	}
+	sumOp = wrapAggregateOp("sum the entire stack", sum)
 
 	swapOp = Op{
 		"swap the top two elements",
@@ -739,28 +926,9 @@ var (
 		},
 	}
 
-	negOp = Op{
-		"negate stack.Top()",
-		func(stack *Stack) (Floats, error) {
-			top, err := stack.Pop()
-			if err != nil {
-				return nil, err
-			}
-			return Floats{-top}, nil
-		},
-	}
+	negOp = wrapUnaryOp("negate stack.Top()", func(x float64) float64 { return -x })
 
-	varOp = Op{
-		"variance of the entire stack",
-		func(stack *Stack) (Floats, error) {
-			stats := welford.New()
-			arr := stack.Copy()
-			for _, n := range arr {
-				stats.Add(n)
-			}
-			return Floats{stats.Variance()}, nil
-		},
-	}
+	varOp = wrapAggregateOp("variance of the entire stack", variance)
 
 	whOp = Op{
 		"watts to horsepower conversion",
@@ -798,30 +966,72 @@ func wrapUnaryOp(doc string, f func(float64) float64) Op {
 	return Op{
 		doc,
 		func(stack *Stack) (Floats, error) {
-			top, err := stack.Pop()
+			top, err := stack.TopValue()
 			if err != nil {
 				return nil, err
 			}
-			result := f(top)
-			return Floats{result}, nil
+			result, err := mapValue(top, f)
+			if err != nil {
+				return nil, err
+			}
+			_, _ = stack.PopValue()
+			return pushResult(stack, result)
 		},
 	}
 }
 
-func wrapBinaryOp(doc string, f func(float64, float64) float64) Op {
+// wrapArithOp applies f to the second and top values, in that order,
+// broadcasting over vectors and matrices.
+func wrapArithOp(doc string, f func(float64, float64) float64) Op {
+	return wrapHookedArithOp(doc, f)
+}
+
+// An arithHook handles operand types that f cannot, such as decimals and
+// dates. It reports false to leave the operands to the next hook or to f.
+type arithHook func(stack *Stack, x, y Value) (Value, bool, error)
+
+// wrapHookedArithOp is wrapArithOp with hooks tried in order before f.
+func wrapHookedArithOp(doc string, f func(float64, float64) float64, hooks ...arithHook) Op {
 	return Op{
 		doc,
 		func(stack *Stack) (Floats, error) {
-			elems, err := stack.PopN(2)
+			elems, err := stack.PopValues(2)
 			if err != nil {
 				return nil, err
 			}
-			result := f(elems[0], elems[1])
-			return Floats{result}, nil
+			for _, hook := range hooks {
+				result, ok, err := hook(stack, elems[0], elems[1])
+				if ok {
+					if err != nil {
+						return nil, err
+					}
+					return pushResult(stack, result)
+				}
+			}
+			result, ok, err := uncertainArith(elems[0], elems[1], f)
+			if ok {
+				if err != nil {
+					return nil, err
+				}
+				return pushResult(stack, result)
+			}
+			result, err = broadcast(elems[0], elems[1], f)
+			if err != nil {
+				stack.PushValue(elems[0])
+				stack.PushValue(elems[1])
+				return nil, err
+			}
+			return pushResult(stack, result)
 		},
 	}
 }
 
+// wrapBinaryOp applies f to the top and second values, in that order,
+// broadcasting over vectors and matrices.
+func wrapBinaryOp(doc string, f func(float64, float64) float64) Op {
+	return wrapArithOp(doc, func(x, y float64) float64 { return f(y, x) })
+}
+
 func wrapTernaryOp(doc string, f func(float64, float64, float64) float64) Op {
 	return Op{
 		doc,
@@ -841,7 +1051,7 @@ func (o *Ops) OpNames() ([]string, int) {
 	longest := math.MinInt
 	for name := range o.opmap {
 		names = append(names, name)
-		longest = max(longest, len(name))
+		longest = max(longest, utf8.RuneCountInString(name))
 	}
 	slices.Sort(names)
 	return names, longest
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Quantile interpolation follows the nine sample quantile definitions of
+// Hyndman and Fan (1996), numbered the same way as R's quantile(type=...).
+// Type 7 is the default in both R and numpy.
+const (
+	_defaultQuantileType = 7
+	_minQuantileType     = 1
+	_maxQuantileType     = 9
+)
+
+func sortedCopy(data []float64) ([]float64, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("insufficient stack")
+	}
+	result := slices.Clone(data)
+	slices.Sort(result)
+	return result, nil
+}
+
+func quantile(sorted []float64, p float64, quantileType int) (float64, error) {
+	n := len(sorted)
+	if n < 1 {
+		return 0.0, fmt.Errorf("insufficient stack")
+	}
+	if p < 0 || p > 1 || math.IsNaN(p) {
+		return 0.0, fmt.Errorf("quantile %g out of range [0, 1]", p)
+	}
+
+	var m float64
+	switch quantileType {
+	case 1, 2:
+		m = 0
+	case 3:
+		m = -0.5
+	case 4:
+		m = 0
+	case 5:
+		m = 0.5
+	case 6:
+		m = p
+	case 7:
+		m = 1 - p
+	case 8:
+		m = (p + 1) / 3
+	case 9:
+		m = p/4 + 3.0/8.0
+	default:
+		return 0.0, fmt.Errorf("unknown quantile type %d", quantileType)
+	}
+
+	np := float64(n)*p + m
+	j := math.Floor(np)
+	g := np - j
+
+	var gamma float64
+	switch quantileType {
+	case 1:
+		if g > 0 {
+			gamma = 1
+		}
+	case 2:
+		gamma = 1
+		if g == 0 {
+			gamma = 0.5
+		}
+	case 3:
+		gamma = 1
+		if g == 0 && int(j)%2 == 0 {
+			gamma = 0
+		}
+	default:
+		gamma = g
+	}
+
+	// j is 1-based in the literature
+	at := func(i float64) float64 {
+		index := min(max(int(i), 1), n)
+		return sorted[index-1]
+	}
+	lo := at(j)
+	hi := at(j + 1)
+	if gamma == 0 {
+		return lo, nil
+	}
+	return (1-gamma)*lo + gamma*hi, nil
+}
+
+func median(data []float64) (float64, error) {
+	sorted, err := sortedCopy(data)
+	if err != nil {
+		return 0.0, err
+	}
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2], nil
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2, nil
+}
+
+func quartiles(data []float64, quantileType int) (Floats, error) {
+	sorted, err := sortedCopy(data)
+	if err != nil {
+		return nil, err
+	}
+	result := Floats{}
+	for _, p := range []float64{0.25, 0.5, 0.75} {
+		q, err := quantile(sorted, p, quantileType)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, q)
+	}
+	return result, nil
+}
+
+func mode(data []float64) (float64, error) {
+	sorted, err := sortedCopy(data)
+	if err != nil {
+		return 0.0, err
+	}
+	best := sorted[0]
+	bestCount := 0
+	for i := 0; i < len(sorted); {
+		j := i
+		for j < len(sorted) && sorted[j] == sorted[i] {
+			j++
+		}
+		if j-i > bestCount {
+			best = sorted[i]
+			bestCount = j - i
+		}
+		i = j
+	}
+	return best, nil
+}
+
+func medianAbsDev(data []float64) (float64, error) {
+	center, err := median(data)
+	if err != nil {
+		return 0.0, err
+	}
+	deviations := make([]float64, 0, len(data))
+	for _, n := range data {
+		deviations = append(deviations, math.Abs(n-center))
+	}
+	return median(deviations)
+}
+
+func geometricMean(data []float64) (float64, error) {
+	if len(data) < 1 {
+		return 0.0, fmt.Errorf("insufficient stack")
+	}
+	logSum := 0.0
+	for _, n := range data {
+		if n <= 0 {
+			return 0.0, fmt.Errorf("geometric mean requires positive values")
+		}
+		logSum += math.Log(n)
+	}
+	return math.Exp(logSum / float64(len(data))), nil
+}
+
+func harmonicMean(data []float64) (float64, error) {
+	if len(data) < 1 {
+		return 0.0, fmt.Errorf("insufficient stack")
+	}
+	reciprocalSum := 0.0
+	for _, n := range data {
+		if n <= 0 {
+			return 0.0, fmt.Errorf("harmonic mean requires positive values")
+		}
+		reciprocalSum += 1 / n
+	}
+	return float64(len(data)) / reciprocalSum, nil
+}
+
+var (
+	medianOp = wrapAggregateOp("median of the entire stack", median)
+	modeOp   = wrapAggregateOp("most frequent value of the entire stack, the smallest on ties", mode)
+	madOp    = wrapAggregateOp("median absolute deviation of the entire stack", medianAbsDev)
+	gmeanOp  = wrapAggregateOp("geometric mean of the entire stack", geometricMean)
+	hmeanOp  = wrapAggregateOp("harmonic mean of the entire stack", harmonicMean)
+
+	rangeOp = wrapAggregateOp("max - min of the entire stack", spread)
+
+	pctOp = Op{
+		"data... p pct pops p and pushes the p-th percentile of the rest of the stack",
+		func(stack *Stack) (Floats, error) {
+			p, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			data, err := stack.Data()
+			if err != nil {
+				return nil, err
+			}
+			sorted, err := sortedCopy(data)
+			if err != nil {
+				return nil, err
+			}
+			result, err := quantile(sorted, p/100, stack.quantileType)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	quartilesOp = Op{
+		"pushes Q1, Q2 and Q3 of the entire stack, Q3 ends up on top",
+		func(stack *Stack) (Floats, error) {
+			data, err := stack.Data()
+			if err != nil {
+				return nil, err
+			}
+			qs, err := quartiles(data, stack.quantileType)
+			if err != nil {
+				return nil, err
+			}
+			slices.Reverse(qs)
+			return qs, nil
+		},
+	}
+
+	iqrOp = Op{
+		"interquartile range Q3 - Q1 of the entire stack",
+		func(stack *Stack) (Floats, error) {
+			data, err := stack.Data()
+			if err != nil {
+				return nil, err
+			}
+			qs, err := quartiles(data, stack.quantileType)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{qs[2] - qs[0]}, nil
+		},
+	}
+
+	npctOp = Op{
+		"data... n p npct pops p, consumes the top n values and pushes their p-th percentile",
+		func(stack *Stack) (Floats, error) {
+			if stack.Len() < 2 {
+				return nil, fmt.Errorf("insufficient stack")
+			}
+			p := stack.PopU()
+			window, err := popWindow(stack)
+			if err != nil {
+				return nil, err
+			}
+			sorted, err := sortedCopy(window)
+			if err != nil {
+				return nil, err
+			}
+			result, err := quantile(sorted, p/100, stack.quantileType)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	nquartilesOp = Op{
+		"n nquartiles consumes the top n values and pushes their Q1, Q2 and Q3, Q3 on top",
+		func(stack *Stack) (Floats, error) {
+			window, err := popWindow(stack)
+			if err != nil {
+				return nil, err
+			}
+			qs, err := quartiles(window, stack.quantileType)
+			if err != nil {
+				return nil, err
+			}
+			slices.Reverse(qs)
+			return qs, nil
+		},
+	}
+
+	niqrOp = Op{
+		"n niqr consumes the top n values and pushes their interquartile range",
+		func(stack *Stack) (Floats, error) {
+			window, err := popWindow(stack)
+			if err != nil {
+				return nil, err
+			}
+			qs, err := quartiles(window, stack.quantileType)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{qs[2] - qs[0]}, nil
+		},
+	}
+
+	qtypeOp = Op{
+		"select quantile interpolation from stack.Top(), 1-9 as in R, 7 is the R/numpy default",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			quantileType := int(top)
+			if float64(quantileType) != top || quantileType < _minQuantileType || quantileType > _maxQuantileType {
+				return nil, fmt.Errorf("quantile type must be an integer from %d to %d", _minQuantileType, _maxQuantileType)
+			}
+			stack.quantileType = quantileType
+			return nil, nil
+		},
+	}
+)
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pushAll(stack *Stack, values ...float64) {
+	for _, n := range values {
+		stack.Push(n)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 5, 1, 4, 2)
+	err := ops.Run("median", stack)
+	assert.Nil(t, err)
+	assertClose(t, 3, topOf(t, stack))
+	assert.Equal(t, 5, stack.Len())
+}
+
+func TestPercentileTypes(t *testing.T) {
+	expected := map[int]float64{
+		1: 3,
+		2: 3,
+		4: 2.5,
+		5: 3,
+		6: 2.75,
+		7: 3.25,
+		8: 2.9166666666666667,
+		9: 2.9375,
+	}
+	for quantileType, want := range expected {
+		stack := NewStack()
+		ops := NewOps()
+		stack.Push(float64(quantileType))
+		err := ops.Run("qtype", stack)
+		assert.Nil(t, err)
+		pushAll(stack, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 25)
+		err = ops.Run("pct", stack)
+		assert.Nil(t, err)
+		assertClose(t, want, topOf(t, stack))
+	}
+}
+
+func TestPercentileType2Averages(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(2)
+	err := ops.Run("qtype", stack)
+	assert.Nil(t, err)
+	pushAll(stack, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 20)
+	err = ops.Run("pct", stack)
+	assert.Nil(t, err)
+	assertClose(t, 2.5, topOf(t, stack))
+}
+
+func TestQtypeRange(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(10)
+	assert.NotNil(t, ops.Run("qtype", stack))
+	stack.Push(2.5)
+	assert.NotNil(t, ops.Run("qtype", stack))
+}
+
+func TestQuartiles(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	err := ops.Run("quartiles", stack)
+	assert.Nil(t, err)
+	results, err := stack.PopN(3)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{7, 5, 3}, results)
+
+	err = ops.Run("iqr", stack)
+	assert.Nil(t, err)
+	assertClose(t, 4, topOf(t, stack))
+}
+
+func TestModeRangeMad(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 1, 2, 2, 4, 6, 9)
+
+	err := ops.Run("mad", stack)
+	assert.Nil(t, err)
+	assertClose(t, 1, stack.PopU())
+
+	err = ops.Run("mode", stack)
+	assert.Nil(t, err)
+	assertClose(t, 1, stack.PopU())
+
+	err = ops.Run("range", stack)
+	assert.Nil(t, err)
+	assertClose(t, 8, stack.PopU())
+}
+
+func TestMeans(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 4)
+
+	err := ops.Run("gmean", stack)
+	assert.Nil(t, err)
+	assertClose(t, 2, stack.PopU())
+
+	err = ops.Run("hmean", stack)
+	assert.Nil(t, err)
+	assertClose(t, 1.7142857142857142, stack.PopU())
+
+	stack.Push(-1)
+	assert.NotNil(t, ops.Run("gmean", stack))
+}
+
+func TestOrderStatsEmpty(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.NotNil(t, ops.Run("median", stack))
+	assert.NotNil(t, ops.Run("quartiles", stack))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The percent ops follow the HP-12C: with a base y under a second value x,
+// %of, %ch and %t replace x with their result and leave y in place, so
+// 200 15 %of + adds 15% to 200. markup and margin consume both prices. In
+// decimal mode the results are rounded to the scale.
+
+var (
+	_hundred = big.NewRat(100, 1)
+)
+
+func percentOf(y, x *big.Rat) (*big.Rat, error) {
+	result := new(big.Rat).Mul(y, x)
+	return result.Quo(result, _hundred), nil
+}
+
+// percentChange measures the change from y to x against |y|, so a move
+// towards positive infinity is always a positive change.
+func percentChange(y, x *big.Rat) (*big.Rat, error) {
+	if y.Sign() == 0 {
+		return nil, fmt.Errorf("percent change from zero is undefined")
+	}
+	result := new(big.Rat).Sub(x, y)
+	result.Mul(result, _hundred)
+	return result.Quo(result, new(big.Rat).Abs(y)), nil
+}
+
+func percentOfTotal(y, x *big.Rat) (*big.Rat, error) {
+	if y.Sign() == 0 {
+		return nil, fmt.Errorf("percent of a zero total is undefined")
+	}
+	result := new(big.Rat).Mul(x, _hundred)
+	return result.Quo(result, y), nil
+}
+
+// markup is the profit as a percentage of the cost y.
+func markup(cost, price *big.Rat) (*big.Rat, error) {
+	if cost.Sign() == 0 {
+		return nil, fmt.Errorf("markup on a zero cost is undefined")
+	}
+	result := new(big.Rat).Sub(price, cost)
+	result.Mul(result, _hundred)
+	return result.Quo(result, cost), nil
+}
+
+// margin is the profit as a percentage of the price x.
+func margin(cost, price *big.Rat) (*big.Rat, error) {
+	if price.Sign() == 0 {
+		return nil, fmt.Errorf("margin on a zero price is undefined")
+	}
+	result := new(big.Rat).Sub(price, cost)
+	result.Mul(result, _hundred)
+	return result.Quo(result, price), nil
+}
+
+var (
+	percentOfOp     = wrapPercentOp("y x %of, x percent of y, keeping y", true, percentOf)
+	percentOp       = wrapPercentOp("y x %, same as %of; the remainder is now mod", true, percentOf)
+	percentChangeOp = wrapPercentOp("y x %ch, percent change from y to x, keeping y", true, percentChange)
+	percentTotalOp  = wrapPercentOp("y x %t, x as a percent of the total y, keeping y", true, percentOfTotal)
+	markupOp        = wrapPercentOp("cost price markup, profit as a percent of cost", false, markup)
+	marginOp        = wrapPercentOp("cost price margin, profit as a percent of price", false, margin)
+)
+
+// wrapPercentOp computes f exactly, as a Decimal when either operand is one
+// and as a float64 otherwise.
+func wrapPercentOp(doc string, keepBase bool, f func(y, x *big.Rat) (*big.Rat, error)) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopValues(2)
+			if err != nil {
+				return nil, err
+			}
+			y, ok := toDecimal(elems[0])
+			if !ok {
+				return nil, fmt.Errorf("expected two numbers but found %s and %s", describeKind(elems[0]), describeKind(elems[1]))
+			}
+			x, ok := toDecimal(elems[1])
+			if !ok {
+				return nil, fmt.Errorf("expected two numbers but found %s and %s", describeKind(elems[0]), describeKind(elems[1]))
+			}
+			result, err := f(y.rat, x.rat)
+			if err != nil {
+				return nil, err
+			}
+			if keepBase {
+				stack.PushValue(elems[0])
+			}
+			_, yIsDecimal := elems[0].(Decimal)
+			_, xIsDecimal := elems[1].(Decimal)
+			if yIsDecimal || xIsDecimal {
+				stack.PushValue(Decimal{stack.decimal.round(result)})
+				return nil, nil
+			}
+			value, _ := result.Float64()
+			return Floats{value}, nil
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentOf(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 200, 15)
+	assert.Nil(t, ops.Run("%of", stack))
+	assert.Equal(t, []float64{200, 30}, scalars(t, stack))
+	assert.Nil(t, ops.Run("+", stack))
+	assert.Equal(t, []float64{230}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 200, -15)
+	assert.Nil(t, ops.Run("%", stack))
+	assert.Equal(t, []float64{200, -30}, scalars(t, stack))
+
+	pushAll(stack, 3, 7)
+	assert.Nil(t, ops.Run("mod", stack))
+	assert.Equal(t, 1.0, stack.PopU())
+}
+
+func TestPercentChange(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	tests := []struct {
+		from, to, change float64
+	}{
+		{50, 60, 20},
+		{50, 40, -20},
+		{-50, -40, 20},
+		{-50, -60, -20},
+		{-50, 50, 200},
+		{40, 0, -100},
+	}
+	for _, test := range tests {
+		pushAll(stack, test.from, test.to)
+		assert.Nil(t, ops.Run("%ch", stack))
+		assertClose(t, test.change, stack.PopU())
+		assert.Equal(t, test.from, stack.PopU())
+	}
+	pushAll(stack, 0, 10)
+	assert.NotNil(t, ops.Run("%ch", stack))
+	assert.Equal(t, []float64{0, 10}, scalars(t, stack))
+}
+
+func TestPercentOfTotal(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 80, 20)
+	assert.Nil(t, ops.Run("%t", stack))
+	assert.Equal(t, []float64{80, 25}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 80, -20)
+	assert.Nil(t, ops.Run("%t", stack))
+	assert.Equal(t, []float64{80, -25}, scalars(t, stack))
+}
+
+func TestMarkupAndMargin(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 80, 100)
+	assert.Nil(t, ops.Run("markup", stack))
+	assert.Equal(t, []float64{25}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 80, 100)
+	assert.Nil(t, ops.Run("margin", stack))
+	assert.Equal(t, []float64{20}, scalars(t, stack))
+
+	stack.Clear()
+	pushAll(stack, 100, 80)
+	assert.Nil(t, ops.Run("margin", stack))
+	assert.Equal(t, []float64{-25}, scalars(t, stack))
+	pushAll(stack, 100, 80)
+	assert.Nil(t, ops.Run("markup", stack))
+	assert.Equal(t, []float64{-25, -20}, scalars(t, stack))
+}
+
+func TestPercentDecimal(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "dec", "19.99", "8.25", "%of")
+	assert.Equal(t, big.NewRat(165, 100), stack.storage[1].(Decimal).rat)
+	runAll(t, stack, ops, "+")
+	assert.Equal(t, "[ 21.64 ]", stack.String())
+
+	runAll(t, stack, ops, "3", "%t")
+	assert.Equal(t, big.NewRat(1386, 100), stack.storage[1].(Decimal).rat)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+)
+
+// Polynomial coefficients are ordered from the highest power down, as in
+// numpy's polyval, so [1 -3 2] is x^2 - 3x + 2. They can be given as a
+// vector or as n scalars followed by the count n.
+
+const (
+	_durandKernerRounds = 1000
+	_rootTolerance      = 1e-12
+	_realRootTolerance  = 1e-9
+	_clusterTolerance   = 1e-2
+	_clusterResidual    = 1e-10
+	_newtonRounds       = 8
+)
+
+type Complex complex128
+
+func (c Complex) Format(format func(float64) string) string {
+	im := imag(c)
+	sign := "+"
+	if math.Signbit(im) {
+		sign = "-"
+		im = -im
+	}
+	return fmt.Sprintf("%s%s%si", format(real(c)), sign, format(im))
+}
+
+func popCoefficients(stack *Stack) (Vector, error) {
+	top, err := stack.TopValue()
+	if err != nil {
+		return nil, err
+	}
+	if vector, ok := top.(Vector); ok {
+		_, _ = stack.PopValue()
+		return slices.Clone(vector), nil
+	}
+	window, err := popWindow(stack)
+	if err != nil {
+		return nil, err
+	}
+	return Vector(window), nil
+}
+
+func trimLeadingZeros(coefficients Vector) Vector {
+	for len(coefficients) > 0 && coefficients[0] == 0 {
+		coefficients = coefficients[1:]
+	}
+	return coefficients
+}
+
+func polyval(coefficients Vector, x float64) float64 {
+	result := 0.0
+	for _, c := range coefficients {
+		result = result*x + c
+	}
+	return result
+}
+
+func polyvalComplex(coefficients Vector, z complex128) complex128 {
+	result := complex(0, 0)
+	for _, c := range coefficients {
+		result = result*z + complex(c, 0)
+	}
+	return result
+}
+
+// polyRoots finds every root with the Durand-Kerner iteration. Roots whose
+// imaginary part is negligible are reported as real, and a root of
+// multiplicity m, which the iteration only finds to about the mth root of
+// the precision, is recovered from its cluster by clusterRoots.
+func polyRoots(coefficients Vector) ([]complex128, error) {
+	coefficients = trimLeadingZeros(coefficients)
+	if len(coefficients) < 2 {
+		return nil, fmt.Errorf("need a polynomial of degree 1 or more")
+	}
+	zeros := 0
+	for coefficients[len(coefficients)-1] == 0 {
+		coefficients = coefficients[:len(coefficients)-1]
+		zeros++
+	}
+	monic := make(Vector, len(coefficients))
+	for i, c := range coefficients {
+		monic[i] = c / coefficients[0]
+	}
+	degree := len(monic) - 1
+	roots := make([]complex128, degree)
+	radius := 1.0
+	for _, c := range monic[1:] {
+		radius = max(radius, math.Abs(c))
+	}
+	seed := complex(0.4, 0.9)
+	for i := range roots {
+		roots[i] = cmplx.Pow(seed, complex(float64(i), 0)) * complex(radius, 0)
+	}
+	for range _durandKernerRounds {
+		largest := 0.0
+		for i := range roots {
+			denominator := complex(1, 0)
+			for j := range roots {
+				if i != j {
+					denominator *= roots[i] - roots[j]
+				}
+			}
+			delta := polyvalComplex(monic, roots[i]) / denominator
+			roots[i] -= delta
+			largest = max(largest, cmplx.Abs(delta)/max(1, cmplx.Abs(roots[i])))
+		}
+		if largest < _rootTolerance {
+			break
+		}
+	}
+	clusterRoots(monic, roots)
+	for range zeros {
+		roots = append(roots, 0)
+	}
+	for i, root := range roots {
+		if math.Abs(imag(root)) <= _realRootTolerance*max(1, cmplx.Abs(root)) {
+			roots[i] = complex(real(root), 0)
+		}
+	}
+	slices.SortFunc(roots, func(a, b complex128) int {
+		aReal, bReal := imag(a) == 0, imag(b) == 0
+		switch {
+		case aReal != bReal:
+			if aReal {
+				return -1
+			}
+			return 1
+		case math.Abs(real(a)-real(b)) > _realRootTolerance*max(1, cmplx.Abs(a)):
+			if real(a) < real(b) {
+				return -1
+			}
+			return 1
+		case imag(a) < imag(b):
+			return -1
+		case imag(a) > imag(b):
+			return 1
+		}
+		return 0
+	})
+	return roots, nil
+}
+
+// clusterRoots replaces each group of nearby roots with a single multiple
+// root when the polynomial vanishes there to within rounding. An m-fold root
+// is a simple root of the (m-1)th derivative, so Newton steps on that from
+// the mean of the group find it to full precision.
+func clusterRoots(monic Vector, roots []complex128) {
+	cluster := make([]int, len(roots))
+	for i := range cluster {
+		cluster[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if cluster[i] != i {
+			cluster[i] = find(cluster[i])
+		}
+		return cluster[i]
+	}
+	for i := range roots {
+		for j := range i {
+			if cmplx.Abs(roots[i]-roots[j]) < _clusterTolerance*max(1, cmplx.Abs(roots[i])) {
+				cluster[find(i)] = find(j)
+			}
+		}
+	}
+	members := map[int][]int{}
+	for i := range roots {
+		members[find(i)] = append(members[find(i)], i)
+	}
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		root := complex(0, 0)
+		for _, i := range group {
+			root += roots[i]
+		}
+		root /= complex(float64(len(group)), 0)
+		derivative := monic
+		for range len(group) - 1 {
+			derivative = polyder(derivative)
+		}
+		slope := polyder(derivative)
+		for range _newtonRounds {
+			d := polyvalComplex(slope, root)
+			if d == 0 {
+				break
+			}
+			root -= polyvalComplex(derivative, root) / d
+		}
+		scale := 0.0
+		for _, c := range monic {
+			scale = scale*cmplx.Abs(root) + math.Abs(c)
+		}
+		if cmplx.Abs(polyvalComplex(monic, root)) > _clusterResidual*scale {
+			continue
+		}
+		for _, i := range group {
+			roots[i] = root
+		}
+	}
+}
+
+// polyder returns the coefficients of the derivative, highest power first.
+func polyder(coefficients Vector) Vector {
+	degree := len(coefficients) - 1
+	derivative := make(Vector, degree)
+	for i := range derivative {
+		derivative[i] = coefficients[i] * float64(degree-i)
+	}
+	return derivative
+}
+
+// polyfit solves the least squares normal equations for a degree n fit.
+func polyfit(xs, ys Vector, degree int) (Vector, error) {
+	if len(xs) != len(ys) {
+		return nil, fmt.Errorf("need as many y values as x values")
+	}
+	if degree < 0 || len(xs) <= degree {
+		return nil, fmt.Errorf("need more than %d points for a degree %d fit", degree, degree)
+	}
+	terms := degree + 1
+	normal := NewMatrix(terms, terms)
+	rhs := make(Vector, terms)
+	for k, x := range xs {
+		powers := make([]float64, terms)
+		p := 1.0
+		for i := terms - 1; i >= 0; i-- {
+			powers[i] = p
+			p *= x
+		}
+		for i := range terms {
+			rhs[i] += powers[i] * ys[k]
+			for j := range terms {
+				normal.Set(i, j, normal.At(i, j)+powers[i]*powers[j])
+			}
+		}
+	}
+	return solveLinear(normal, rhs)
+}
+
+var (
+	polyOp = Op{
+		"coefficients x poly, evaluate the polynomial at x",
+		func(stack *Stack) (Floats, error) {
+			x, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			coefficients, err := popCoefficients(stack)
+			if err != nil {
+				return nil, err
+			}
+			result, err := mapValue(x, func(n float64) float64 {
+				return polyval(coefficients, n)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, result)
+		},
+	}
+
+	rootsOp = Op{
+		"coefficients roots, push every real root then every complex root",
+		func(stack *Stack) (Floats, error) {
+			coefficients, err := popCoefficients(stack)
+			if err != nil {
+				return nil, err
+			}
+			roots, err := polyRoots(coefficients)
+			if err != nil {
+				return nil, err
+			}
+			for _, root := range roots {
+				if imag(root) == 0 {
+					stack.Push(real(root))
+				} else {
+					stack.PushValue(Complex(root))
+				}
+			}
+			return nil, nil
+		},
+	}
+
+	polyfitOp = Op{
+		"xs ys n polyfit, least squares coefficients of a degree n polynomial",
+		func(stack *Stack) (Floats, error) {
+			degree, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if degree < 0 || degree != math.Trunc(degree) {
+				return nil, fmt.Errorf("degree must be a non-negative integer")
+			}
+			elems, err := stack.PopValues(2)
+			if err != nil {
+				return nil, err
+			}
+			xs, err := asVector(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			ys, err := asVector(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			result, err := polyfit(xs, ys, int(degree))
+			if err != nil {
+				return nil, err
+			}
+			return pushResult(stack, result)
+		},
+	}
+
+	realOp = wrapComplexOp("real part of a complex value", func(c complex128) float64 { return real(c) })
+	imagOp = wrapComplexOp("imaginary part of a complex value", func(c complex128) float64 { return imag(c) })
+	cabsOp = wrapComplexOp("modulus of a complex value", cmplx.Abs)
+	cargOp = wrapComplexOp("argument of a complex value, in radians", cmplx.Phase)
+)
+
+func wrapComplexOp(doc string, f func(complex128) float64) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			switch t := top.(type) {
+			case Complex:
+				return Floats{f(complex128(t))}, nil
+			case Scalar:
+				return Floats{f(complex(float64(t), 0))}, nil
+			}
+			stack.PushValue(top)
+			return nil, fmt.Errorf("expected a complex value but found %s", describeKind(top))
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoly(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[1 -3 2]")
+	stack.Push(5)
+	assert.Nil(t, ops.Run("poly", stack))
+	assert.Equal(t, 12.0, stack.PopU())
+
+	pushAll(stack, 2, 0, 1, 3, 2)
+	assert.Nil(t, ops.Run("poly", stack))
+	assert.Equal(t, 9.0, stack.PopU())
+
+	pushLiteral(t, stack, "[1 0 0]")
+	pushLiteral(t, stack, "[1 2 3]")
+	assert.Nil(t, ops.Run("poly", stack))
+	assert.Equal(t, "[ [1 4 9] ]", stack.String())
+}
+
+func TestRealRoots(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[1 -6 11 -6]")
+	assert.Nil(t, ops.Run("roots", stack))
+	results, err := stack.PopR(3)
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{1, 2, 3}, results, 1e-9)
+
+	pushAll(stack, 2, 0, -8, 3)
+	assert.Nil(t, ops.Run("roots", stack))
+	results, err = stack.PopR(2)
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{-2, 2}, results, 1e-9)
+}
+
+func TestRepeatedRoots(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[1 -3 3 -1]")
+	assert.Nil(t, ops.Run("roots", stack))
+	results, err := stack.PopR(3)
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{1, 1, 1}, results, 1e-9)
+
+	// (x-2)²(x+1)
+	roots, err := polyRoots(Vector{1, -3, 0, 4})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{-1, 2, 2}, []float64{real(roots[0]), real(roots[1]), real(roots[2])}, 1e-9)
+	for _, root := range roots {
+		assert.Equal(t, 0.0, imag(root))
+	}
+
+	// distinct roots close together are not merged
+	roots, err = polyRoots(Vector{1, -2.0005, 1.0005})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, real(roots[0]), 1e-9)
+	assert.InDelta(t, 1.0005, real(roots[1]), 1e-9)
+}
+
+func TestComplexRoots(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[1 -1 1 -1]")
+	assert.Nil(t, ops.Run("roots", stack))
+	assert.Equal(t, 3, stack.Len())
+	values := stack.Values()
+	assert.InDelta(t, 1, float64(values[0].(Scalar)), 1e-9)
+	assert.InDelta(t, -1, imag(complex128(values[1].(Complex))), 1e-9)
+	assert.InDelta(t, 1, imag(complex128(values[2].(Complex))), 1e-9)
+
+	assert.Nil(t, ops.Run("im", stack))
+	assertClose(t, 1, stack.PopU())
+	assert.Nil(t, ops.Run("cabs", stack))
+	assertClose(t, 1, stack.PopU())
+	assert.Equal(t, "[ 0+2i ]", (&Stack{storage: []Value{Complex(2i)}}).String())
+}
+
+func TestRootsWithZeros(t *testing.T) {
+	roots, err := polyRoots(Vector{0, 1, -1, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(roots))
+	assert.InDelta(t, 0, real(roots[0]), 1e-12)
+	assert.InDelta(t, 1, real(roots[1]), 1e-12)
+
+	_, err = polyRoots(Vector{0, 5})
+	assert.NotNil(t, err)
+}
+
+func TestPolyfit(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushLiteral(t, stack, "[0 1 2 3 4]")
+	pushLiteral(t, stack, "[1 3 9 19 33]")
+	stack.Push(2)
+	assert.Nil(t, ops.Run("polyfit", stack))
+	fit, err := stack.PopValue()
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{2, 0, 1}, elements(fit), 1e-9)
+
+	pushLiteral(t, stack, "[1 2]")
+	pushLiteral(t, stack, "[1 2]")
+	stack.Push(2)
+	assert.NotNil(t, ops.Run("polyfit", stack))
+}
+
+func TestComplexOnScalar(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(-2)
+	assert.Nil(t, ops.Run("carg", stack))
+	assertClose(t, math.Pi, stack.PopU())
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Qm.n values are signed two's complement fixed point numbers with m integer
+// bits, n fractional bits and a sign bit, so Q15 is Q0.15 in 16 bits. Codes
+// wider than a float64 mantissa cannot be held exactly and are rejected.
+
+type QRounding int
+
+const (
+	QRoundNearest QRounding = iota
+	QRoundEven
+	QRoundFloor
+	QRoundZero
+)
+
+const (
+	_maxQWidth = 53
+)
+
+type QConfig struct {
+	rounding QRounding
+	wrap     bool
+}
+
+func (r QRounding) apply(x float64) float64 {
+	switch r {
+	case QRoundEven:
+		return math.RoundToEven(x)
+	case QRoundFloor:
+		return math.Floor(x)
+	case QRoundZero:
+		return math.Trunc(x)
+	default:
+		return math.Round(x)
+	}
+}
+
+func checkQFormat(m, n float64) (int, int, error) {
+	if m < 0 || n < 0 || m != math.Trunc(m) || n != math.Trunc(n) || m+n+1 > _maxQWidth {
+		return 0, 0, fmt.Errorf("format Q%g.%g must have non-negative integer m and n with m+n < %d", m, n, _maxQWidth)
+	}
+	return int(m), int(n), nil
+}
+
+// quantize returns the two's complement code of x in Qm.n, saturating or
+// wrapping out of range values as configured.
+func (c QConfig) quantize(x float64, m, n int) (float64, error) {
+	if math.IsNaN(x) {
+		return 0, fmt.Errorf("cannot quantize NaN")
+	}
+	width := m + n + 1
+	lo := -math.Ldexp(1, width-1)
+	hi := math.Ldexp(1, width-1) - 1
+	code := c.rounding.apply(math.Ldexp(x, n))
+	if code >= lo && code <= hi {
+		return code, nil
+	}
+	if !c.wrap || math.IsInf(code, 0) {
+		return min(max(code, lo), hi), nil
+	}
+	span := math.Ldexp(1, width)
+	code = math.Mod(code-lo, span)
+	if code < 0 {
+		code += span
+	}
+	return code + lo, nil
+}
+
+// dequantize accepts either a signed code or the unsigned bit pattern of a
+// negative code, e.g. 0x8000 for -1 in Q15.
+func dequantize(code float64, m, n int) (float64, error) {
+	width := m + n + 1
+	if code != math.Trunc(code) || code < -math.Ldexp(1, width-1) || code >= math.Ldexp(1, width) {
+		return 0, fmt.Errorf("%g is not a %d-bit Q%d.%d code", code, width, m, n)
+	}
+	if code >= math.Ldexp(1, width-1) {
+		code -= math.Ldexp(1, width)
+	}
+	return math.Ldexp(code, -n), nil
+}
+
+func hexCode(code float64, width int) string {
+	bits := uint64(int64(code)) & (1<<width - 1)
+	return fmt.Sprintf("0x%0*X", (width+3)/4, bits)
+}
+
+func toQ(stack *Stack, x float64, m, n int) (Floats, error) {
+	code, err := stack.qconfig.quantize(x, m, n)
+	if err != nil {
+		return nil, err
+	}
+	value, err := dequantize(code, m, n)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Q%d.%d %s error %g\n", m, n, hexCode(code, m+n+1), value-x)
+	return Floats{code}, nil
+}
+
+var (
+	toQOp = Op{
+		"x m n toq, quantize x to a Qm.n code and print the quantization error",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			m, n, err := checkQFormat(elems[1], elems[2])
+			if err != nil {
+				return nil, err
+			}
+			return toQ(stack, elems[0], m, n)
+		},
+	}
+
+	fromQOp = Op{
+		"code m n fromq, the real value of a Qm.n code",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(3)
+			if err != nil {
+				return nil, err
+			}
+			m, n, err := checkQFormat(elems[1], elems[2])
+			if err != nil {
+				return nil, err
+			}
+			value, err := dequantize(elems[0], m, n)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{value}, nil
+		},
+	}
+
+	toQ15Op   = wrapToQOp(0, 15)
+	toQ31Op   = wrapToQOp(0, 31)
+	fromQ15Op = wrapFromQOp(0, 15)
+	fromQ31Op = wrapFromQOp(0, 31)
+
+	qRoundNearestOp = wrapQRoundingOp("round Q conversions to nearest, ties away from zero", QRoundNearest)
+	qRoundEvenOp    = wrapQRoundingOp("round Q conversions to nearest, ties to even", QRoundEven)
+	qRoundFloorOp   = wrapQRoundingOp("round Q conversions toward negative infinity", QRoundFloor)
+	qRoundZeroOp    = wrapQRoundingOp("round Q conversions toward zero", QRoundZero)
+
+	qSaturateOp = Op{
+		"saturate out of range Q conversions",
+		func(stack *Stack) (Floats, error) {
+			stack.qconfig.wrap = false
+			return nil, nil
+		},
+	}
+
+	qWrapOp = Op{
+		"wrap out of range Q conversions modulo the word size",
+		func(stack *Stack) (Floats, error) {
+			stack.qconfig.wrap = true
+			return nil, nil
+		},
+	}
+)
+
+func wrapToQOp(m, n int) Op {
+	return Op{
+		fmt.Sprintf("quantize stack.Top() to a Q%d.%d code and print the quantization error", m, n),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			return toQ(stack, top, m, n)
+		},
+	}
+}
+
+func wrapFromQOp(m, n int) Op {
+	return Op{
+		fmt.Sprintf("the real value of the Q%d.%d code on stack.Top()", m, n),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			value, err := dequantize(top, m, n)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{value}, nil
+		},
+	}
+}
+
+func wrapQRoundingOp(doc string, rounding QRounding) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			stack.qconfig.rounding = rounding
+			return nil, nil
+		},
+	}
+}
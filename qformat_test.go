@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToQ15(t *testing.T) {
+	cases := []struct {
+		value float64
+		code  float64
+	}{
+		{0.5, 16384},
+		{-1, -32768},
+		{0.999, 32735},
+		{1, 32767},
+		{-2, -32768},
+		{0.1, 3277},
+	}
+	for _, c := range cases {
+		stack := NewStack()
+		ops := NewOps()
+		stack.Push(c.value)
+		assert.Nil(t, ops.Run("q15", stack))
+		assert.Equal(t, c.code, topOf(t, stack), "%g", c.value)
+	}
+}
+
+func TestToQmn(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 3.14159, 3, 12)
+	assert.Nil(t, ops.Run("toq", stack))
+	assert.Equal(t, 12868.0, topOf(t, stack))
+
+	pushAll(stack, 1.5, 0)
+	assert.NotNil(t, ops.Run("toq", stack))
+}
+
+func TestQRoundingAndWrap(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.Nil(t, ops.Run("qrfloor", stack))
+	stack.Push(-0.1)
+	assert.Nil(t, ops.Run("q15", stack))
+	assert.Equal(t, -3277.0, stack.PopU())
+
+	assert.Nil(t, ops.Run("qrzero", stack))
+	stack.Push(-0.1)
+	assert.Nil(t, ops.Run("q15", stack))
+	assert.Equal(t, -3276.0, stack.PopU())
+
+	assert.Nil(t, ops.Run("qreven", stack))
+	pushAll(stack, 2.5, 7, 0)
+	assert.Nil(t, ops.Run("toq", stack))
+	assert.Equal(t, 2.0, stack.PopU())
+
+	assert.Nil(t, ops.Run("qwrap", stack))
+	stack.Push(1)
+	assert.Nil(t, ops.Run("q15", stack))
+	assert.Equal(t, -32768.0, stack.PopU())
+
+	assert.Nil(t, ops.Run("qsat", stack))
+	stack.Push(1)
+	assert.Nil(t, ops.Run("q15", stack))
+	assert.Equal(t, 32767.0, stack.PopU())
+}
+
+func TestFromQ(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(0x8000)
+	assert.Nil(t, ops.Run("fromq15", stack))
+	assert.Equal(t, -1.0, stack.PopU())
+
+	stack.Push(0x4000)
+	assert.Nil(t, ops.Run("fromq15", stack))
+	assert.Equal(t, 0.5, stack.PopU())
+
+	stack.Push(-0x40000000)
+	assert.Nil(t, ops.Run("fromq31", stack))
+	assert.Equal(t, -0.5, stack.PopU())
+
+	pushAll(stack, 12868, 3, 12)
+	assert.Nil(t, ops.Run("fromq", stack))
+	assert.Equal(t, 3.1416015625, stack.PopU())
+
+	stack.Push(0x10000)
+	assert.NotNil(t, ops.Run("fromq15", stack))
+}
+
+func TestHexCode(t *testing.T) {
+	assert.Equal(t, "0x8000", hexCode(-32768, 16))
+	assert.Equal(t, "0xFFFF", hexCode(-1, 16))
+	assert.Equal(t, "0x3244", hexCode(12868, 16))
+}
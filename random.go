@@ -0,0 +1,197 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+)
+
+const (
+	_maxDice = 10000
+)
+
+// By default random numbers come from crypto/rand. Seeding switches the stack
+// to a PCG generator so that a session can be replayed exactly.
+
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	_, err := crand.Read(b[:])
+	if err != nil {
+		panic(err)
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func newCryptoRand() *rand.Rand {
+	return rand.New(cryptoSource{})
+}
+
+func newSeededRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// poissonSample uses Knuth's multiplication method for small means and
+// Hörmann's PTRS transformed rejection for large ones.
+func poissonSample(rng *rand.Rand, lambda float64) float64 {
+	if lambda < 10 {
+		limit := math.Exp(-lambda)
+		k := 0.0
+		p := rng.Float64()
+		for p > limit {
+			k++
+			p *= rng.Float64()
+		}
+		return k
+	}
+	slam := math.Sqrt(lambda)
+	loglam := math.Log(lambda)
+	b := 0.931 + 2.53*slam
+	a := -0.059 + 0.02483*b
+	invalpha := 1.1239 + 1.1328/(b-3.4)
+	vr := 0.9277 - 3.6224/(b-2)
+	for {
+		u := rng.Float64() - 0.5
+		v := rng.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return k
+		}
+		if k < 0 || (us < 0.013 && v > us) {
+			continue
+		}
+		lk, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invalpha)-math.Log(a/(us*us)+b) <= -lambda+k*loglam-lk {
+			return k
+		}
+	}
+}
+
+var _diceRegexp = regexp.MustCompile(`^(\d*)d(\d+)([+-]\d+)?$`)
+
+// tryDice rolls dice notation such as d20, 3d6 or 2d8+3.
+func tryDice(line string, stack *Stack) error {
+	matches := _diceRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return fmt.Errorf("not dice notation")
+	}
+	count := 1
+	if len(matches[1]) > 0 {
+		n, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return err
+		}
+		count = n
+	}
+	sides, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return err
+	}
+	if count < 1 || count > _maxDice || sides < 1 {
+		return fmt.Errorf("dice must be 1 to %d dice of at least 1 side", _maxDice)
+	}
+	total := 0
+	if len(matches[3]) > 0 {
+		total, err = strconv.Atoi(matches[3])
+		if err != nil {
+			return err
+		}
+	}
+	for range count {
+		total += stack.rng.IntN(sides) + 1
+	}
+	stack.Push(float64(total))
+	return nil
+}
+
+var (
+	seedOp = Op{
+		"seed the random number generator with stack.Top() for reproducible results",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			stack.rng = newSeededRand(uint64(int64(top)))
+			return nil, nil
+		},
+	}
+
+	unseedOp = Op{
+		"return to the unseeded crypto/rand random number generator",
+		func(stack *Stack) (Floats, error) {
+			stack.rng = newCryptoRand()
+			return nil, nil
+		},
+	}
+
+	rand01Op = Op{
+		"uniform random float in [0, 1)",
+		func(stack *Stack) (Floats, error) {
+			return Floats{stack.rng.Float64()}, nil
+		},
+	}
+
+	randNormOp = Op{
+		"μ σ randnorm, normally distributed random number",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			if elems[1] < 0 {
+				return nil, fmt.Errorf("σ must not be negative")
+			}
+			return Floats{elems[0] + elems[1]*stack.rng.NormFloat64()}, nil
+		},
+	}
+
+	randExpOp = Op{
+		"λ randexp, exponentially distributed random number with rate λ",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkPositive("λ", top); err != nil {
+				return nil, err
+			}
+			return Floats{stack.rng.ExpFloat64() / top}, nil
+		},
+	}
+
+	randPoissOp = Op{
+		"λ randpoiss, Poisson distributed random count with mean λ",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkPositive("λ", top); err != nil {
+				return nil, err
+			}
+			return Floats{poissonSample(stack.rng, top)}, nil
+		},
+	}
+
+	shuffleOp = Op{
+		"shuffle the entire stack",
+		func(stack *Stack) (Floats, error) {
+			values := stack.Values()
+			stack.rng.Shuffle(len(values), func(i, j int) {
+				values[i], values[j] = values[j], values[i]
+			})
+			stack.Clear()
+			for _, v := range values {
+				stack.PushValue(v)
+			}
+			return nil, nil
+		},
+	}
+)
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seededStack(t *testing.T, ops *Ops, seed float64) *Stack {
+	stack := NewStack()
+	stack.Push(seed)
+	err := ops.Run("seed", stack)
+	assert.Nil(t, err)
+	return stack
+}
+
+func TestSeedExact(t *testing.T) {
+	ops := NewOps()
+	stack := seededStack(t, ops, 42)
+
+	err := ops.Run("rand01", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.3050145593494096, stack.PopU())
+
+	stack.Push(100)
+	err = ops.Run("rn", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, 37.0, stack.PopU())
+
+	err = tryDice("3d6+2", stack)
+	assert.Nil(t, err)
+	assert.Equal(t, 16.0, stack.PopU())
+}
+
+func TestSeedReproducible(t *testing.T) {
+	ops := NewOps()
+	run := func() []float64 {
+		stack := seededStack(t, ops, 7)
+		for _, op := range []string{"r", "rand01"} {
+			assert.Nil(t, ops.Run(op, stack))
+		}
+		pushAll(stack, 10, 2)
+		assert.Nil(t, ops.Run("randnorm", stack))
+		stack.Push(3)
+		assert.Nil(t, ops.Run("randexp", stack))
+		stack.Push(50)
+		assert.Nil(t, ops.Run("randpoiss", stack))
+		assert.Nil(t, ops.Run("shuffle", stack))
+		return scalars(t, stack)
+	}
+	assert.Equal(t, run(), run())
+}
+
+func TestPoissonSampleMean(t *testing.T) {
+	ops := NewOps()
+	for _, lambda := range []float64{3, 40} {
+		stack := seededStack(t, ops, 1)
+		const draws = 20000
+		total := 0.0
+		for range draws {
+			stack.Push(lambda)
+			assert.Nil(t, ops.Run("randpoiss", stack))
+			total += stack.PopU()
+		}
+		assert.InDelta(t, lambda, total/draws, lambda*0.02)
+	}
+}
+
+func TestShuffleKeepsValues(t *testing.T) {
+	ops := NewOps()
+	stack := seededStack(t, ops, 3)
+	pushAll(stack, 1, 2, 3, 4, 5)
+	assert.Nil(t, ops.Run("shuffle", stack))
+	assert.Equal(t, 5, stack.Len())
+	stack.Sort()
+	assert.Equal(t, []float64{1, 2, 3, 4, 5}, scalars(t, stack))
+}
+
+func TestDice(t *testing.T) {
+	stack := NewStack()
+	for range 100 {
+		assert.Nil(t, tryDice("2d6-1", stack))
+		roll := stack.PopU()
+		assert.GreaterOrEqual(t, roll, 1.0)
+		assert.LessOrEqual(t, roll, 11.0)
+	}
+	assert.Nil(t, tryDice("d1", stack))
+	assert.Equal(t, 1.0, stack.PopU())
+	assert.NotNil(t, tryDice("0d6", stack))
+	assert.NotNil(t, tryDice("3d", stack))
+	assert.NotNil(t, tryDice("sd", stack))
+}
+
+func TestRandNBound(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(0)
+	assert.NotNil(t, ops.Run("rn", stack))
+}
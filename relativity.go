@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The relativity ops take velocities in m/s and reject any at or beyond c.
+// They work in β = v/c internally, so the formulas read as in a textbook.
+
+// beta is v as a fraction of the speed of light.
+func beta(v float64) (float64, error) {
+	b := v / _c
+	if math.Abs(b) >= 1 || math.IsNaN(b) {
+		return 0, fmt.Errorf("velocity %g m/s is not below the speed of light", v)
+	}
+	return b, nil
+}
+
+func lorentz(b float64) float64 {
+	return 1 / math.Sqrt(1-b*b)
+}
+
+// lorentzMinusOne is γ - 1 written as β²/(s(1+s)) with s = √(1-β²), which
+// keeps its precision at low velocity where γ - 1 would cancel.
+func lorentzMinusOne(b float64) float64 {
+	s := math.Sqrt(1 - b*b)
+	return b * b / (s * (1 + s))
+}
+
+var (
+	timeDilationOp = wrapRelativityOp("t v tdil, time t of a clock moving at v as seen from rest", func(t, b float64) float64 {
+		return t * lorentz(b)
+	})
+
+	lengthContractionOp = wrapRelativityOp("l v lcon, length l moving at v as seen from rest", func(l, b float64) float64 {
+		return l / lorentz(b)
+	})
+
+	kineticEnergyOp = wrapRelativityOp("m v ke, kinetic energy in J of rest mass m kg moving at v", func(m, b float64) float64 {
+		return lorentzMinusOne(b) * m * _c * _c
+	})
+
+	dopplerOp = wrapRelativityOp("f v doppler, frequency f from a source receding at v, approaching if v is negative", func(f, b float64) float64 {
+		return f * math.Sqrt((1-b)/(1+b))
+	})
+
+	velocityAdditionOp = Op{
+		"u v vadd, relativistic sum of the velocities u and v",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			u, err := beta(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			v, err := beta(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{(u + v) / (1 + u*v) * _c}, nil
+		},
+	}
+
+	rapidityOp = Op{
+		"v rapid, rapidity of the velocity v, which adds where velocities do not",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			b, err := beta(top)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{math.Atanh(b)}, nil
+		},
+	}
+)
+
+// wrapRelativityOp pops x and a velocity and pushes f of x and the velocity
+// as a fraction of c.
+func wrapRelativityOp(doc string, f func(x, b float64) float64) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			b, err := beta(elems[1])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{f(elems[0], b)}, nil
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// At everyday speeds every op must agree with its Newtonian counterpart to
+// about (v/c)², which is 1e-14 at 30 m/s.
+const _newtonian = 1e-13
+
+func TestRelativityNewtonianLimit(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	runAll(t, stack, ops, "3600", "30", "tdil")
+	assert.InEpsilon(t, 3600, stack.PopU(), _newtonian)
+
+	runAll(t, stack, ops, "100", "30", "lcon")
+	assert.InEpsilon(t, 100, stack.PopU(), _newtonian)
+
+	runAll(t, stack, ops, "20", "30", "vadd")
+	assert.InEpsilon(t, 50, stack.PopU(), _newtonian)
+
+	runAll(t, stack, ops, "1500", "30", "ke")
+	assert.InEpsilon(t, 0.5*1500*30*30, stack.PopU(), _newtonian)
+
+	runAll(t, stack, ops, "30", "rapid")
+	assert.InEpsilon(t, 30/_c, stack.PopU(), _newtonian)
+
+	// the classical Doppler shift for a moving source is f/(1+v/c)
+	runAll(t, stack, ops, "1e9", "30", "doppler")
+	assert.InEpsilon(t, 1e9/(1+30/_c), stack.PopU(), _newtonian)
+}
+
+func TestRelativityFastValues(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	v := _c * math.Sqrt(3) / 2 // γ = 2
+
+	stack.Push(1)
+	stack.Push(v)
+	assert.Nil(t, ops.Run("tdil", stack))
+	assertClose(t, 2, stack.PopU())
+
+	stack.Push(1)
+	stack.Push(v)
+	assert.Nil(t, ops.Run("lcon", stack))
+	assertClose(t, 0.5, stack.PopU())
+
+	stack.Push(1)
+	stack.Push(v)
+	assert.Nil(t, ops.Run("ke", stack))
+	assert.InEpsilon(t, _c*_c, stack.PopU(), 1e-12)
+
+	stack.Push(_c / 2)
+	stack.Push(_c / 2)
+	assert.Nil(t, ops.Run("vadd", stack))
+	assert.InEpsilon(t, 0.8*_c, stack.PopU(), 1e-12)
+
+	// rapidities add where velocities do not
+	stack.Push(0.8 * _c)
+	assert.Nil(t, ops.Run("rapid", stack))
+	sum := stack.PopU()
+	stack.Push(_c / 2)
+	assert.Nil(t, ops.Run("rapid", stack))
+	assertClose(t, sum, 2*stack.PopU())
+
+	stack.Push(100)
+	stack.Push(0.6 * _c)
+	assert.Nil(t, ops.Run("doppler", stack))
+	assertClose(t, 50, stack.PopU())
+	stack.Push(100)
+	stack.Push(-0.6 * _c)
+	assert.Nil(t, ops.Run("doppler", stack))
+	assertClose(t, 200, stack.PopU())
+}
+
+func TestRelativityLimit(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	for _, op := range []string{"tdil", "lcon", "ke", "doppler", "vadd"} {
+		stack.Push(1)
+		stack.Push(_c)
+		assert.NotNil(t, ops.Run(op, stack), op)
+		assert.Equal(t, 2, stack.Len(), op)
+		stack.Clear()
+	}
+	stack.Push(-_c)
+	assert.NotNil(t, ops.Run("rapid", stack))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// An op can be repeated with a count before it, as in 5 x ++. There is no
+// suffix form, since pi*2 reads as an expression. The repeat runs as one
+// transaction: if any round fails the stack is left as it was, and a single
+// undo reverses all of it.
+
+const (
+	_maxRepeat = 10000
+)
+
+var (
+	_repeatRe = regexp.MustCompile(`^(\d+)\s*x\s+(\S+)$`)
+)
+
+// Repeat tracks the last operation, which an empty line runs again when
+// enabled.
+type Repeat struct {
+	disabled bool
+	lastOp   string
+}
+
+func parseRepeat(line string, ops *Ops) (string, int, bool) {
+	match := _repeatRe.FindStringSubmatch(line)
+	if match == nil {
+		return "", 0, false
+	}
+	count, name := match[1], match[2]
+	if _, ok := ops.opmap[name]; !ok {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return "", 0, false
+	}
+	return name, n, true
+}
+
+func tryRepeat(line string, stack *Stack, ops *Ops) error {
+	name, count, ok := parseRepeat(line, ops)
+	if !ok {
+		return fmt.Errorf("not a repeat")
+	}
+	if count > _maxRepeat {
+		return failed(fmt.Errorf("repeat count must be at most %d", _maxRepeat))
+	}
+	saved := stack.Snapshot()
+	for range count {
+		err := ops.Run(name, stack)
+		if err != nil {
+			stack.Restore(saved)
+			return failed(err)
+		}
+	}
+	return nil
+}
+
+var (
+	repeatOnOp = Op{
+		"an empty line repeats the last operation",
+		func(stack *Stack) (Floats, error) {
+			stack.repeat.disabled = false
+			return nil, nil
+		},
+	}
+
+	repeatOffOp = Op{
+		"an empty line does nothing",
+		func(stack *Stack) (Floats, error) {
+			stack.repeat.disabled = true
+			return nil, nil
+		},
+	}
+)
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepeatCounts(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(1)
+	assert.Nil(t, cascade("5 x ++", stack, ops))
+	assert.Equal(t, []float64{6}, scalars(t, stack))
+	assert.Nil(t, cascade("3 x ++", stack, ops))
+	assert.Equal(t, []float64{9}, scalars(t, stack))
+	assert.Nil(t, cascade("2x --", stack, ops))
+	assert.Equal(t, []float64{7}, scalars(t, stack))
+
+	assert.Nil(t, cascade("2*5", stack, ops))
+	assert.Equal(t, []float64{7, 10}, scalars(t, stack))
+
+	_, _, ok := parseRepeat("pi*2", ops)
+	assert.False(t, ok)
+	_, _, ok = parseRepeat("++*2", ops)
+	assert.False(t, ok)
+	assert.Nil(t, cascade("2 x pi", stack, ops))
+	assert.Equal(t, []float64{7, 10, math.Pi, math.Pi}, scalars(t, stack))
+}
+
+func TestRepeatRollsBack(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, 3)
+	assert.True(t, isFailed(cascade("5 x +", stack, ops)))
+	assert.Equal(t, []float64{1, 2, 3}, scalars(t, stack))
+	assert.Empty(t, stack.repeat.lastOp)
+	assert.Empty(t, stack.history.snapshots)
+	assert.True(t, isFailed(cascade("10001 x ++", stack, ops)))
+	assert.Nil(t, cascade("2 x +", stack, ops))
+	assert.Equal(t, []float64{6}, scalars(t, stack))
+}
+
+func TestEmptyLineRepeatsOperation(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.Nil(t, cascade("3", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{3}, scalars(t, stack))
+
+	assert.Nil(t, cascade("++", stack, ops))
+	assert.Nil(t, cascade("7", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{4, 9}, scalars(t, stack))
+
+	assert.Nil(t, cascade("2 x --", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{4, 5}, scalars(t, stack))
+
+	assert.Nil(t, cascade("norep", stack, ops))
+	assert.Nil(t, cascade("++", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{4, 6}, scalars(t, stack))
+
+	assert.Nil(t, cascade("rep", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{4, 6}, scalars(t, stack))
+	assert.Nil(t, cascade("++", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{4, 8}, scalars(t, stack))
+}
+
+func TestRunRollsBack(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, 1, 2, -1)
+	assert.NotNil(t, ops.Run("npct", stack))
+	assert.Equal(t, []float64{1, 2, -1}, scalars(t, stack))
+}
+
+func TestCascadeReportsOpErrors(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "1")
+	err := cascade("+", stack, ops)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "insufficient stack", err.Error())
+	}
+	assert.Equal(t, []float64{1}, scalars(t, stack))
+	assert.Empty(t, stack.repeat.lastOp)
+
+	runAll(t, stack, ops, "++")
+	stack.Clear()
+	runAll(t, stack, ops, "[1 2]")
+	assert.NotNil(t, cascade("", stack, ops))
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Sigma is an HP-style two-variable statistics register. It keeps running
+// sums rather than a welford accumulator so that entries can be removed again
+// with Σ-.
+type Sigma struct {
+	n     float64
+	sumX  float64
+	sumY  float64
+	sumXX float64
+	sumYY float64
+	sumXY float64
+}
+
+func (s *Sigma) Add(x, y float64) {
+	s.n++
+	s.sumX += x
+	s.sumY += y
+	s.sumXX += x * x
+	s.sumYY += y * y
+	s.sumXY += x * y
+}
+
+func (s *Sigma) Remove(x, y float64) error {
+	if s.n < 1 {
+		return fmt.Errorf("statistics register is empty")
+	}
+	s.n--
+	s.sumX -= x
+	s.sumY -= y
+	s.sumXX -= x * x
+	s.sumYY -= y * y
+	s.sumXY -= x * y
+	return nil
+}
+
+func (s *Sigma) Clear() {
+	*s = Sigma{}
+}
+
+func (s *Sigma) N() float64 {
+	return s.n
+}
+
+func (s *Sigma) MeanX() (float64, error) {
+	if s.n < 1 {
+		return 0.0, fmt.Errorf("statistics register is empty")
+	}
+	return s.sumX / s.n, nil
+}
+
+func (s *Sigma) MeanY() (float64, error) {
+	if s.n < 1 {
+		return 0.0, fmt.Errorf("statistics register is empty")
+	}
+	return s.sumY / s.n, nil
+}
+
+// sxx, syy and sxy are the corrected sums of squares and products.
+func (s *Sigma) sxx() float64 {
+	return s.sumXX - s.sumX*s.sumX/s.n
+}
+
+func (s *Sigma) syy() float64 {
+	return s.sumYY - s.sumY*s.sumY/s.n
+}
+
+func (s *Sigma) sxy() float64 {
+	return s.sumXY - s.sumX*s.sumY/s.n
+}
+
+func (s *Sigma) StddevX() (float64, error) {
+	if s.n < 2 {
+		return 0.0, fmt.Errorf("need at least two entries")
+	}
+	return math.Sqrt(max(s.sxx(), 0) / (s.n - 1)), nil
+}
+
+func (s *Sigma) StddevY() (float64, error) {
+	if s.n < 2 {
+		return 0.0, fmt.Errorf("need at least two entries")
+	}
+	return math.Sqrt(max(s.syy(), 0) / (s.n - 1)), nil
+}
+
+func (s *Sigma) Correlation() (float64, error) {
+	if s.n < 2 {
+		return 0.0, fmt.Errorf("need at least two entries")
+	}
+	denom := math.Sqrt(s.sxx() * s.syy())
+	if denom == 0 {
+		return 0.0, fmt.Errorf("correlation undefined for constant data")
+	}
+	return s.sxy() / denom, nil
+}
+
+func (s *Sigma) Slope() (float64, error) {
+	if s.n < 2 {
+		return 0.0, fmt.Errorf("need at least two entries")
+	}
+	sxx := s.sxx()
+	if sxx == 0 {
+		return 0.0, fmt.Errorf("slope undefined for constant x")
+	}
+	return s.sxy() / sxx, nil
+}
+
+func (s *Sigma) Intercept() (float64, error) {
+	slope, err := s.Slope()
+	if err != nil {
+		return 0.0, err
+	}
+	return (s.sumY - slope*s.sumX) / s.n, nil
+}
+
+func (s *Sigma) PredictY(x float64) (float64, error) {
+	slope, err := s.Slope()
+	if err != nil {
+		return 0.0, err
+	}
+	intercept, err := s.Intercept()
+	if err != nil {
+		return 0.0, err
+	}
+	return intercept + slope*x, nil
+}
+
+func (s *Sigma) PredictX(y float64) (float64, error) {
+	slope, err := s.Slope()
+	if err != nil {
+		return 0.0, err
+	}
+	if slope == 0 {
+		return 0.0, fmt.Errorf("cannot predict x from a horizontal fit")
+	}
+	intercept, err := s.Intercept()
+	if err != nil {
+		return 0.0, err
+	}
+	return (y - intercept) / slope, nil
+}
+
+var (
+	sigmaAddOp = Op{
+		"x y Σ+ accumulates the pair into the statistics register and pushes n",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			stack.sigma.Add(elems[0], elems[1])
+			return Floats{stack.sigma.N()}, nil
+		},
+	}
+
+	sigmaRemoveOp = Op{
+		"x y Σ- removes a previously accumulated pair and pushes n",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			err = stack.sigma.Remove(elems[0], elems[1])
+			if err != nil {
+				return nil, err
+			}
+			return Floats{stack.sigma.N()}, nil
+		},
+	}
+
+	sigmaClearOp = Op{
+		"clear the statistics register",
+		func(stack *Stack) (Floats, error) {
+			stack.sigma.Clear()
+			return nil, nil
+		},
+	}
+
+	sigmaNOp = Op{
+		"number of pairs in the statistics register",
+		func(stack *Stack) (Floats, error) {
+			return Floats{stack.sigma.N()}, nil
+		},
+	}
+
+	sigmaMeanOp = Op{
+		"means of x and y from the statistics register, x̄ on top of ȳ",
+		func(stack *Stack) (Floats, error) {
+			mx, err := stack.sigma.MeanX()
+			if err != nil {
+				return nil, err
+			}
+			my, err := stack.sigma.MeanY()
+			if err != nil {
+				return nil, err
+			}
+			return Floats{mx, my}, nil
+		},
+	}
+
+	sigmaMeanXOp = wrapSigmaOp("mean of x from the statistics register", (*Sigma).MeanX)
+	sigmaMeanYOp = wrapSigmaOp("mean of y from the statistics register", (*Sigma).MeanY)
+	sigmaSdXOp   = wrapSigmaOp("sample standard deviation of x from the statistics register", (*Sigma).StddevX)
+	sigmaSdYOp   = wrapSigmaOp("sample standard deviation of y from the statistics register", (*Sigma).StddevY)
+	sigmaCorrOp  = wrapSigmaOp("correlation coefficient of the statistics register", (*Sigma).Correlation)
+	sigmaSlopeOp = wrapSigmaOp("least-squares slope of the statistics register", (*Sigma).Slope)
+	sigmaIcptOp  = wrapSigmaOp("least-squares intercept of the statistics register", (*Sigma).Intercept)
+
+	sigmaYHatOp = Op{
+		"x ŷ predicts y for x from the least-squares fit",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			result, err := stack.sigma.PredictY(top)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+
+	sigmaXHatOp = Op{
+		"y x̂ predicts x for y from the least-squares fit",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			result, err := stack.sigma.PredictX(top)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+)
+
+func wrapSigmaOp(doc string, f func(*Sigma) (float64, error)) Op {
+	return Op{
+		doc,
+		func(stack *Stack) (Floats, error) {
+			result, err := f(&stack.sigma)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{result}, nil
+		},
+	}
+}
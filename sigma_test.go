@@ -0,0 +1,107 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pushPairs(t *testing.T, stack *Stack, ops *Ops, pairs [][2]float64) {
+	for _, pair := range pairs {
+		stack.Push(pair[0])
+		stack.Push(pair[1])
+		err := ops.Run("Σ+", stack)
+		assert.Nil(t, err)
+		_, _ = stack.Pop()
+	}
+}
+
+func TestSigmaRegression(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushPairs(t, stack, ops, [][2]float64{{1, 3}, {2, 5}, {3, 7}, {4, 9}})
+
+	err := ops.Run("sn", stack)
+	assert.Nil(t, err)
+	assertClose(t, 4, stack.PopU())
+
+	err = ops.Run("slope", stack)
+	assert.Nil(t, err)
+	assertClose(t, 2, stack.PopU())
+
+	err = ops.Run("icept", stack)
+	assert.Nil(t, err)
+	assertClose(t, 1, stack.PopU())
+
+	err = ops.Run("corr", stack)
+	assert.Nil(t, err)
+	assertClose(t, 1, stack.PopU())
+
+	stack.Push(10)
+	err = ops.Run("ŷ", stack)
+	assert.Nil(t, err)
+	assertClose(t, 21, stack.PopU())
+
+	stack.Push(21)
+	err = ops.Run("xhat", stack)
+	assert.Nil(t, err)
+	assertClose(t, 10, stack.PopU())
+
+	stack.Push(21)
+	err = ops.Run("x̂", stack)
+	assert.Nil(t, err)
+	assertClose(t, 10, stack.PopU())
+	assert.True(t, stack.Empty())
+}
+
+func TestSigmaMeanAndSd(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushPairs(t, stack, ops, [][2]float64{{2, 10}, {4, 20}, {4, 30}, {4, 40}, {5, 50}, {5, 60}, {7, 70}, {9, 80}})
+
+	err := ops.Run("smean", stack)
+	assert.Nil(t, err)
+	assertClose(t, 5, stack.PopU())
+	assertClose(t, 45, stack.PopU())
+
+	err = ops.Run("sdx", stack)
+	assert.Nil(t, err)
+	assertClose(t, 2.138089935299395, stack.PopU())
+
+	err = ops.Run("sdy", stack)
+	assert.Nil(t, err)
+	assertClose(t, 24.494897427831781, stack.PopU())
+}
+
+func TestSigmaRemove(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushPairs(t, stack, ops, [][2]float64{{1, 3}, {2, 5}, {100, -7}, {3, 7}})
+
+	stack.Push(100)
+	stack.Push(-7)
+	err := ops.Run("Σ-", stack)
+	assert.Nil(t, err)
+	assertClose(t, 3, stack.PopU())
+
+	err = ops.Run("slope", stack)
+	assert.Nil(t, err)
+	assertClose(t, 2, stack.PopU())
+
+	err = ops.Run("sclr", stack)
+	assert.Nil(t, err)
+	stack.Push(1)
+	stack.Push(1)
+	err = ops.Run("Σ-", stack)
+	assert.NotNil(t, err)
+}
+
+func TestSigmaInsufficient(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	err := ops.Run("mx", stack)
+	assert.NotNil(t, err)
+	pushPairs(t, stack, ops, [][2]float64{{1, 3}})
+	err = ops.Run("slope", stack)
+	assert.NotNil(t, err)
+}
@@ -2,28 +2,68 @@ package main
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"slices"
 	"strings"
+	"time"
 )
 
 type Stack struct {
-	storage []float64
+	storage      []Value
+	sigma        Sigma
+	quantileType int
+	rng          *rand.Rand
+	display      Display
+	qconfig      QConfig
+	function     *Function
+	history      History
+	repeat       Repeat
+	tvm          TVM
+	ballistics   Ballistics
+	decimal      DecimalConfig
+	zone         *time.Location
+	uncertain    UncertainConfig
 }
 
 func NewStack() *Stack {
-	return &Stack{}
+	return &Stack{
+		quantileType: _defaultQuantileType,
+		rng:          newCryptoRand(),
+		display:      NewDisplay(),
+		tvm:          NewTVM(),
+		ballistics:   NewBallistics(),
+		decimal:      NewDecimalConfig(),
+		zone:         time.Local,
+	}
 }
 
 func (s *Stack) Push(value float64) {
+	s.storage = append(s.storage, Scalar(value))
+}
+
+func (s *Stack) PushValue(value Value) {
 	s.storage = append(s.storage, value)
 }
 
-func (s *Stack) Top() float64 {
+// Top reads the top of the stack without popping it.
+func (s *Stack) Top() (float64, error) {
+	top, err := s.TopValue()
+	if err != nil {
+		return 0.0, err
+	}
+	scalar, ok := scalarOf(top)
+	if !ok {
+		return 0.0, fmt.Errorf("expected a scalar but found %s", describeKind(top))
+	}
+	return scalar, nil
+}
+
+func (s *Stack) TopValue() (Value, error) {
 	size := len(s.storage)
 	if size < 1 {
-		return 0.0
+		return nil, fmt.Errorf("insufficient stack")
 	}
-	return s.storage[size-1]
+	return s.storage[size-1], nil
 }
 
 func (s *Stack) PopU() float64 {
@@ -43,10 +83,16 @@ func (s *Stack) PopN(n int) ([]float64, error) {
 	if len(s.storage) < n {
 		return nil, fmt.Errorf("insufficient stack")
 	}
+	for i := len(s.storage) - n; i < len(s.storage); i++ {
+		if _, ok := scalarOf(s.storage[i]); !ok {
+			return nil, fmt.Errorf("expected a scalar but found %s", describeKind(s.storage[i]))
+		}
+	}
 	result := []float64{}
 	for range n {
 		index := len(s.storage) - 1
-		result = append(result, s.storage[index])
+		scalar, _ := scalarOf(s.storage[index])
+		result = append(result, scalar)
 		s.storage = s.storage[:index]
 	}
 	return result, nil
@@ -61,19 +107,46 @@ func (s *Stack) PopR(n int) ([]float64, error) {
 	return result, nil
 }
 
+func (s *Stack) PopValue() (Value, error) {
+	values, err := s.PopValues(1)
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// PopValues returns the top n values in stack order, top last, like PopR.
+func (s *Stack) PopValues(n int) ([]Value, error) {
+	if len(s.storage) < n {
+		return nil, fmt.Errorf("insufficient stack")
+	}
+	index := len(s.storage) - n
+	result := slices.Clone(s.storage[index:])
+	s.storage = s.storage[:index]
+	return result, nil
+}
+
 func (s *Stack) Swap() error {
-	topTwo, err := s.PopN(2)
+	topTwo, err := s.PopValues(2)
 	if err != nil {
 		return err
 	}
-	for _, n := range topTwo {
-		s.Push(n)
+	s.PushValue(topTwo[1])
+	s.PushValue(topTwo[0])
+	return nil
+}
+
+func (s *Stack) Dup() error {
+	top, err := s.TopValue()
+	if err != nil {
+		return err
 	}
+	s.PushValue(top)
 	return nil
 }
 
 func (s *Stack) Clear() {
-	s.storage = []float64{}
+	s.storage = []Value{}
 }
 
 func (s *Stack) Len() int {
@@ -85,11 +158,23 @@ func (s *Stack) Empty() bool {
 }
 
 func (s *Stack) StringImpl(verb string) string {
+	return s.StringFunc(func(n float64) string {
+		return fmt.Sprintf(verb, n)
+	})
+}
+
+func (s *Stack) StringFunc(format func(float64) string) string {
+	return s.stringValues(func(v Value) string {
+		return v.Format(format)
+	})
+}
+
+func (s *Stack) stringValues(format func(Value) string) string {
 	stackSize := s.Len()
 	var b strings.Builder
 	fmt.Fprintf(&b, "[ ")
-	for i, n := range s.storage {
-		fmt.Fprintf(&b, verb, n)
+	for i, v := range s.storage {
+		fmt.Fprint(&b, format(v))
 		if i < stackSize-1 {
 			fmt.Fprintf(&b, "  ")
 		}
@@ -99,19 +184,74 @@ func (s *Stack) StringImpl(verb string) string {
 }
 
 func (s *Stack) String() string {
-	return s.StringImpl("%g")
+	format := func(v Value) string {
+		return v.Format(s.display.Format)
+	}
+	if s.decimal.enabled {
+		format = s.decimal.FormatValue
+	}
+	return s.stringValues(func(v Value) string {
+		return s.uncertain.FormatValue(v, format)
+	})
 }
 
 func (s *Stack) StringF() string {
 	return s.StringImpl("%f")
 }
 
-func (s *Stack) Sort() {
-	slices.Sort(s.storage)
+func (s *Stack) Sort() error {
+	floats, err := s.scalars()
+	if err != nil {
+		return err
+	}
+	slices.Sort(floats)
+	s.Clear()
+	for _, n := range floats {
+		s.Push(n)
+	}
+	return nil
 }
 
-func (s *Stack) Copy() []float64 {
+// Copy returns the stack as float64s, failing if it holds anything but
+// scalars; use Values when they need to be handled.
+func (s *Stack) Copy() ([]float64, error) {
 	result := make([]float64, s.Len())
-	copy(result, s.storage)
-	return result
+	for i, v := range s.storage {
+		scalar, ok := scalarOf(v)
+		if !ok {
+			return nil, fmt.Errorf("expected a scalar but found %s", describeKind(v))
+		}
+		result[i] = scalar
+	}
+	return result, nil
+}
+
+func (s *Stack) Values() []Value {
+	return slices.Clone(s.storage)
+}
+
+func (s *Stack) scalars() ([]float64, error) {
+	result := make([]float64, 0, s.Len())
+	for _, v := range s.storage {
+		scalar, ok := scalarOf(v)
+		if !ok {
+			return nil, fmt.Errorf("stack holds %s, not just scalars", describeKind(v))
+		}
+		result = append(result, scalar)
+	}
+	return result, nil
+}
+
+// Data is what the whole-stack aggregate ops work on: the elements of the
+// vector or matrix on top of the stack if there is one, otherwise every
+// scalar on the stack.
+func (s *Stack) Data() ([]float64, error) {
+	top, err := s.TopValue()
+	if err == nil {
+		switch top.(type) {
+		case Vector, Matrix:
+			return slices.Clone(elements(top)), nil
+		}
+	}
+	return s.scalars()
 }
@@ -6,6 +6,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// topOf reads the scalar on top of the stack, failing the test if there is
+// none.
+func topOf(t *testing.T, stack *Stack) float64 {
+	t.Helper()
+	top, err := stack.Top()
+	assert.Nil(t, err)
+	return top
+}
+
+// scalars copies the stack, failing the test if it holds anything but
+// scalars.
+func scalars(t *testing.T, stack *Stack) []float64 {
+	t.Helper()
+	values, err := stack.Copy()
+	assert.Nil(t, err)
+	return values
+}
+
 func TestPush(t *testing.T) {
 	stack := NewStack()
 	stack.Push(10)
@@ -57,6 +75,14 @@ func TestSwap(t *testing.T) {
 	assert.Equal(t, []float64{2, 3}, results)
 }
 
+func TestDup(t *testing.T) {
+	stack := NewStack()
+	assert.NotNil(t, stack.Dup())
+	stack.PushValue(Vector{1, 2})
+	assert.Nil(t, stack.Dup())
+	assert.Equal(t, []Value{Vector{1, 2}, Vector{1, 2}}, stack.Values())
+}
+
 func TestClear(t *testing.T) {
 	stack := NewStack()
 	stack.Push(1)
@@ -89,6 +115,18 @@ func TestCopy(t *testing.T) {
 	stack.Push(3)
 	stack.Push(1)
 	stack.Push(2)
-	arr := stack.Copy()
+	arr, err := stack.Copy()
+	assert.Nil(t, err)
 	assert.Equal(t, []float64{3, 1, 2}, arr)
+
+	pushLiteral(t, stack, "[1 2]")
+	_, err = stack.Copy()
+	assert.NotNil(t, err)
+	_, err = stack.Top()
+	assert.NotNil(t, err)
+	assert.NotNil(t, NewOps().Run("isnan", stack))
+
+	stack.Clear()
+	_, err = stack.Top()
+	assert.NotNil(t, err)
 }
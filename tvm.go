@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// The TVM registers follow the HP-12C: n periods, i the annual interest rate
+// in percent, PV, PMT and FV, with money paid out negative and money received
+// positive. i is divided by the periods per year, which defaults to 1 so that
+// i is the periodic rate as on the 12C. The n and i ops are tvmn and tvmi, so
+// that single letters stay free. Storing a register consumes the top of the
+// stack; tvmn? through fv? solve for that register from the other four.
+// After fincents, money comes out as Decimals of whole cents, and the
+// amortisation schedule adds them up exactly.
+
+const (
+	_defaultPeriodsPerYear = 1
+)
+
+// _cents rounds money to whole cents, half to even.
+var _cents = DecimalConfig{scale: 2, rounding: DecimalHalfEven}
+
+// _rateBrackets are periodic rates tried in turn to bracket a root for the
+// interest rate and IRR solvers.
+var _rateBrackets = []float64{-0.999, -0.9, -0.5, -0.2, -0.05, -0.01, 1e-9, 0.01, 0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10}
+
+type TVM struct {
+	n              float64
+	i              float64
+	pv             float64
+	pmt            float64
+	fv             float64
+	begin          bool
+	periodsPerYear float64
+	cents          bool
+}
+
+func NewTVM() TVM {
+	return TVM{periodsPerYear: _defaultPeriodsPerYear}
+}
+
+func (t TVM) rate() float64 {
+	return t.i / 100 / t.periodsPerYear
+}
+
+// annuity is (1+rb)((1+r)^n - 1)/r, the growth of a PMT stream, taking its
+// limit n at r = 0.
+func (t TVM) annuity(r float64) float64 {
+	if r == 0 {
+		return t.n
+	}
+	due := 1.0
+	if t.begin {
+		due += r
+	}
+	return due * math.Expm1(t.n*math.Log1p(r)) / r
+}
+
+// residual is zero when the five registers are consistent.
+func (t TVM) residual(r float64) float64 {
+	return t.pv*math.Pow(1+r, t.n) + t.pmt*t.annuity(r) + t.fv
+}
+
+// cents is x to the nearest cent, exactly.
+func cents(x float64) (*big.Rat, error) {
+	d, ok := toDecimal(Scalar(x))
+	if !ok {
+		return nil, fmt.Errorf("%g is not an amount of money", x)
+	}
+	return _cents.round(d.rat), nil
+}
+
+// money is x as a Decimal of whole cents in cents mode, and as it is
+// otherwise.
+func (t TVM) money(x float64) (Value, error) {
+	if !t.cents {
+		return Scalar(x), nil
+	}
+	rat, err := cents(x)
+	if err != nil {
+		return nil, err
+	}
+	return Decimal{rat}, nil
+}
+
+func (t TVM) solveFV() (float64, error) {
+	r := t.rate()
+	return -(t.pv*math.Pow(1+r, t.n) + t.pmt*t.annuity(r)), nil
+}
+
+func (t TVM) solvePV() (float64, error) {
+	r := t.rate()
+	return -(t.fv + t.pmt*t.annuity(r)) / math.Pow(1+r, t.n), nil
+}
+
+func (t TVM) solvePMT() (float64, error) {
+	r := t.rate()
+	annuity := t.annuity(r)
+	if annuity == 0 {
+		return 0, fmt.Errorf("need n other than 0 to solve for PMT")
+	}
+	return -(t.fv + t.pv*math.Pow(1+r, t.n)) / annuity, nil
+}
+
+func (t TVM) solveN() (float64, error) {
+	r := t.rate()
+	if r == 0 {
+		if t.pmt == 0 {
+			return 0, fmt.Errorf("need a PMT or an interest rate to solve for n")
+		}
+		return -(t.pv + t.fv) / t.pmt, nil
+	}
+	due := 1.0
+	if t.begin {
+		due += r
+	}
+	growth := (t.pmt*due - t.fv*r) / (t.pmt*due + t.pv*r)
+	if growth <= 0 || math.IsInf(growth, 0) || math.IsNaN(growth) {
+		return 0, fmt.Errorf("no number of periods balances these values")
+	}
+	return math.Log(growth) / math.Log1p(r), nil
+}
+
+func (t TVM) solveI() (float64, error) {
+	r, err := solveRate(func(r float64) (float64, error) {
+		return t.residual(r), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return r * 100 * t.periodsPerYear, nil
+}
+
+// solveRate finds a periodic rate where f changes sign, scanning
+// _rateBrackets for a bracket and refining it with Brent's method.
+func solveRate(f func(float64) (float64, error)) (float64, error) {
+	lo := _rateBrackets[0]
+	flo, err := f(lo)
+	if err != nil {
+		return 0, err
+	}
+	for _, hi := range _rateBrackets[1:] {
+		fhi, err := f(hi)
+		if err != nil {
+			return 0, err
+		}
+		if flo == 0 {
+			return lo, nil
+		}
+		if math.Signbit(flo) != math.Signbit(fhi) {
+			return brent(f, lo, hi)
+		}
+		lo, flo = hi, fhi
+	}
+	return 0, fmt.Errorf("no interest rate balances these cash flows")
+}
+
+func npv(r float64, flows []float64) float64 {
+	total := 0.0
+	for k, flow := range flows {
+		total += flow / math.Pow(1+r, float64(k))
+	}
+	return total
+}
+
+func irr(flows []float64) (float64, error) {
+	if len(flows) < 2 {
+		return 0, fmt.Errorf("need at least two cash flows")
+	}
+	return solveRate(func(r float64) (float64, error) {
+		return npv(r, flows), nil
+	})
+}
+
+// amortize returns the interest, principal and balance of each of the first
+// periods payments. In cents mode the interest is rounded to the cent each
+// period, as the lender would, and the rest is exact, so the columns add up.
+func (t TVM) amortize(periods int) ([][3]Value, error) {
+	rows := make([][3]Value, 0, periods)
+	r := t.rate()
+	if !t.cents {
+		balance := t.pv
+		for range periods {
+			accruing := balance
+			if t.begin {
+				accruing += t.pmt
+			}
+			interest := -accruing * r
+			principal := t.pmt - interest
+			balance += principal
+			rows = append(rows, [3]Value{Scalar(interest), Scalar(principal), Scalar(balance)})
+		}
+		return rows, nil
+	}
+	balance, err := cents(t.pv)
+	if err != nil {
+		return nil, err
+	}
+	pmt, err := cents(t.pmt)
+	if err != nil {
+		return nil, err
+	}
+	for range periods {
+		accruing := balance
+		if t.begin {
+			accruing = new(big.Rat).Add(balance, pmt)
+		}
+		f, _ := accruing.Float64()
+		interest, err := cents(-f * r)
+		if err != nil {
+			return nil, err
+		}
+		principal := new(big.Rat).Sub(pmt, interest)
+		balance = new(big.Rat).Add(balance, principal)
+		rows = append(rows, [3]Value{Decimal{interest}, Decimal{principal}, Decimal{balance}})
+	}
+	return rows, nil
+}
+
+// addMoney adds two amounts, exactly when both are Decimals.
+func addMoney(x, y Value) Value {
+	xd, xIsDecimal := x.(Decimal)
+	yd, yIsDecimal := y.(Decimal)
+	if xIsDecimal && yIsDecimal {
+		return Decimal{new(big.Rat).Add(xd.rat, yd.rat)}
+	}
+	xf, _ := scalarOf(x)
+	yf, _ := scalarOf(y)
+	return Scalar(xf + yf)
+}
+
+// moneyString shows an amount to the cent.
+func moneyString(v Value) string {
+	if d, ok := v.(Decimal); ok {
+		return d.rat.FloatString(2)
+	}
+	x, _ := scalarOf(v)
+	return fmt.Sprintf("%.2f", x)
+}
+
+func (t TVM) String() string {
+	mode := "end"
+	if t.begin {
+		mode = "begin"
+	}
+	return fmt.Sprintf("tvmn %g  tvmi %g%%  pv %g  pmt %g  fv %g  (%s, %g per year)",
+		t.n, t.i, t.pv, t.pmt, t.fv, mode, t.periodsPerYear)
+}
+
+var (
+	tvmNOp   = wrapStoreTVMOp("tvmn", "number of periods", func(t *TVM) *float64 { return &t.n })
+	tvmIOp   = wrapStoreTVMOp("tvmi", "annual interest rate in percent", func(t *TVM) *float64 { return &t.i })
+	tvmPVOp  = wrapStoreTVMOp("pv", "present value", func(t *TVM) *float64 { return &t.pv })
+	tvmPMTOp = wrapStoreTVMOp("pmt", "payment per period", func(t *TVM) *float64 { return &t.pmt })
+	tvmFVOp  = wrapStoreTVMOp("fv", "future value", func(t *TVM) *float64 { return &t.fv })
+
+	tvmSolveNOp   = wrapSolveTVMOp("tvmn", false, func(t *TVM) *float64 { return &t.n }, TVM.solveN)
+	tvmSolveIOp   = wrapSolveTVMOp("tvmi", false, func(t *TVM) *float64 { return &t.i }, TVM.solveI)
+	tvmSolvePVOp  = wrapSolveTVMOp("pv", true, func(t *TVM) *float64 { return &t.pv }, TVM.solvePV)
+	tvmSolvePMTOp = wrapSolveTVMOp("pmt", true, func(t *TVM) *float64 { return &t.pmt }, TVM.solvePMT)
+	tvmSolveFVOp  = wrapSolveTVMOp("fv", true, func(t *TVM) *float64 { return &t.fv }, TVM.solveFV)
+
+	tvmShowOp = Op{
+		"print the TVM registers",
+		func(stack *Stack) (Floats, error) {
+			fmt.Println(stack.tvm)
+			return nil, nil
+		},
+	}
+
+	tvmClearOp = Op{
+		"clear the TVM registers, keeping the payment mode and periods per year",
+		func(stack *Stack) (Floats, error) {
+			t := NewTVM()
+			t.begin = stack.tvm.begin
+			t.periodsPerYear = stack.tvm.periodsPerYear
+			t.cents = stack.tvm.cents
+			stack.tvm = t
+			return nil, nil
+		},
+	}
+
+	tvmBeginOp = Op{
+		"TVM payments at the beginning of each period",
+		func(stack *Stack) (Floats, error) {
+			stack.tvm.begin = true
+			return nil, nil
+		},
+	}
+
+	tvmEndOp = Op{
+		"TVM payments at the end of each period",
+		func(stack *Stack) (Floats, error) {
+			stack.tvm.begin = false
+			return nil, nil
+		},
+	}
+
+	tvmPeriodsOp = Op{
+		"set the number of compounding periods per year for i",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			if top <= 0 || math.IsInf(top, 0) || math.IsNaN(top) {
+				return nil, fmt.Errorf("periods per year must be positive")
+			}
+			stack.tvm.periodsPerYear = top
+			return nil, nil
+		},
+	}
+
+	tvmCentsOp = Op{
+		"give TVM money results and amortisation as exact decimal cents",
+		func(stack *Stack) (Floats, error) {
+			stack.tvm.cents = true
+			return nil, nil
+		},
+	}
+
+	tvmExactOp = Op{
+		"keep TVM money results unrounded",
+		func(stack *Stack) (Floats, error) {
+			stack.tvm.cents = false
+			return nil, nil
+		},
+	}
+
+	npvOp = Op{
+		"rate npv, net present value of the cash flows on the stack at rate percent per period, bottom first",
+		func(stack *Stack) (Floats, error) {
+			rate, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			flows, err := stack.Data()
+			if err != nil {
+				return nil, err
+			}
+			if len(flows) < 1 {
+				return nil, fmt.Errorf("insufficient stack")
+			}
+			value, err := stack.tvm.money(npv(rate/100, flows))
+			if err != nil {
+				return nil, err
+			}
+			stack.PushValue(value)
+			return nil, nil
+		},
+	}
+
+	irrOp = Op{
+		"internal rate of return in percent per period of the cash flows on the stack, bottom first",
+		func(stack *Stack) (Floats, error) {
+			flows, err := stack.Data()
+			if err != nil {
+				return nil, err
+			}
+			r, err := irr(flows)
+			if err != nil {
+				return nil, err
+			}
+			return Floats{r * 100}, nil
+		},
+	}
+
+	amortOp = Op{
+		"k amort, print the first k payments from the TVM registers and push total principal and total interest",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			periods, err := checkSequenceLength(top)
+			if err != nil {
+				return nil, err
+			}
+			rows, err := stack.tvm.amortize(periods)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("%6s %14s %14s %16s\n", "period", "interest", "principal", "balance")
+			totalInterest, err := stack.tvm.money(0)
+			if err != nil {
+				return nil, err
+			}
+			totalPrincipal := totalInterest
+			for k, row := range rows {
+				fmt.Printf("%6d %14s %14s %16s\n", k+1, moneyString(row[0]), moneyString(row[1]), moneyString(row[2]))
+				totalInterest = addMoney(totalInterest, row[0])
+				totalPrincipal = addMoney(totalPrincipal, row[1])
+			}
+			stack.PushValue(totalPrincipal)
+			stack.PushValue(totalInterest)
+			return nil, nil
+		},
+	}
+)
+
+func wrapStoreTVMOp(name, doc string, register func(*TVM) *float64) Op {
+	return Op{
+		fmt.Sprintf("store stack.Top() in the TVM %s register, the %s", name, doc),
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.Pop()
+			if err != nil {
+				return nil, err
+			}
+			*register(&stack.tvm) = top
+			return nil, nil
+		},
+	}
+}
+
+// wrapSolveTVMOp solves for a register, rounding the result to the cent in
+// cents mode when it is money.
+func wrapSolveTVMOp(name string, money bool, register func(*TVM) *float64, solve func(TVM) (float64, error)) Op {
+	return Op{
+		fmt.Sprintf("solve the TVM registers for %s, store it and push it", name),
+		func(stack *Stack) (Floats, error) {
+			result, err := solve(stack.tvm)
+			if err != nil {
+				return nil, err
+			}
+			value := Value(Scalar(result))
+			if money {
+				value, err = stack.tvm.money(result)
+				if err != nil {
+					return nil, err
+				}
+			}
+			*register(&stack.tvm), _ = scalarOf(value)
+			stack.PushValue(value)
+			return nil, nil
+		},
+	}
+}
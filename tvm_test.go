@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runAll(t *testing.T, stack *Stack, ops *Ops, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		assert.Nil(t, cascade(line, stack, ops), line)
+	}
+}
+
+func TestTVMMortgage(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "12", "ppy", "360", "tvmn", "6", "tvmi", "200000", "pv", "0", "fv", "pmt?")
+	assert.InDelta(t, -1199.10, stack.PopU(), 0.005)
+
+	runAll(t, stack, ops, "0", "tvmi", "tvmi?")
+	assert.InDelta(t, 6, stack.PopU(), 1e-9)
+
+	runAll(t, stack, ops, "0", "tvmn", "tvmn?")
+	assert.InDelta(t, 360, stack.PopU(), 1e-6)
+
+	runAll(t, stack, ops, "0", "pv", "pv?")
+	assert.InDelta(t, 200000, stack.PopU(), 1e-6)
+
+	runAll(t, stack, ops, "120", "tvmn", "fv?")
+	assert.InDelta(t, -167371.45, stack.PopU(), 0.01)
+}
+
+func TestTVMBeginMode(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "12", "tvmn", "1", "tvmi", "1000", "pv", "pmt?")
+	end := stack.PopU()
+	assert.InDelta(t, -88.85, end, 0.005)
+	runAll(t, stack, ops, "beg", "pmt?")
+	assert.InDelta(t, end/1.01, stack.PopU(), 1e-9)
+	runAll(t, stack, ops, "0", "tvmn", "tvmn?")
+	assert.InDelta(t, 12, stack.PopU(), 1e-9)
+}
+
+func TestTVMZeroRate(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "10", "tvmn", "0", "tvmi", "-1000", "pv", "pmt?")
+	assert.InDelta(t, 100, stack.PopU(), 1e-9)
+	runAll(t, stack, ops, "tvmn?")
+	assert.InDelta(t, 10, stack.PopU(), 1e-9)
+	assert.NotNil(t, ops.Run("pmt", stack))
+}
+
+func TestNPVAndIRR(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	pushAll(stack, -1000, 300, 400, 500)
+	stack.Push(10)
+	assert.Nil(t, ops.Run("npv", stack))
+	assert.InDelta(t, -21.04, stack.PopU(), 0.005)
+	assert.Equal(t, 4, stack.Len())
+
+	assert.Nil(t, ops.Run("irr", stack))
+	rate := stack.PopU()
+	assert.InDelta(t, 8.896, rate, 0.001)
+	stack.Push(rate)
+	assert.Nil(t, ops.Run("npv", stack))
+	assert.InDelta(t, 0, stack.PopU(), 1e-9)
+
+	stack.Clear()
+	pushAll(stack, 100, 100)
+	assert.NotNil(t, ops.Run("irr", stack))
+}
+
+func TestAmortCents(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "fincents", "12", "ppy", "360", "tvmn", "6", "tvmi", "200000", "pv", "pmt?")
+	payment, err := stack.PopValue()
+	assert.Nil(t, err)
+	assert.Equal(t, "-1199.10", _cents.FormatValue(payment))
+	assert.Equal(t, -1199.10, stack.tvm.pmt)
+
+	runAll(t, stack, ops, "360", "amort")
+	interest, err := stack.PopValue()
+	assert.Nil(t, err)
+	principal, err := stack.PopValue()
+	assert.Nil(t, err)
+	total := addMoney(interest, principal)
+	if assert.IsType(t, Decimal{}, total) {
+		assert.Equal(t, "-431676.00", total.(Decimal).rat.FloatString(2))
+	}
+	// the rounded payment leaves a balance, which the principal accounts for
+	// to the cent
+	rows, err := stack.tvm.amortize(360)
+	assert.Nil(t, err)
+	assert.Equal(t, "-199998.96", moneyString(principal))
+	assert.Equal(t, "1.04", moneyString(rows[359][2]))
+
+	assert.Equal(t, "-1000.00", moneyString(rows[0][0]))
+	assert.Equal(t, "-199.10", moneyString(rows[0][1]))
+	assert.Equal(t, "199800.90", moneyString(rows[0][2]))
+
+	runAll(t, stack, ops, "finexact", "pmt?")
+	assert.InDelta(t, -1199.10, stack.PopU(), 0.005)
+}
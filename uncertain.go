@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// An Uncertain value such as 9.81±0.02 carries its standard uncertainty
+// through arithmetic and the math wrappers to first order. Each literal is an
+// independent source of error, and a value remembers how much of each source
+// it holds, so dup * doubles the relative error of x rather than adding it
+// in quadrature. Values are shown in the concise notation 9.81(2) or after
+// upm as 9.81 ± 0.02, rounded to the significant figures of the uncertainty.
+// Ops that cannot propagate an uncertainty refuse an Uncertain operand.
+
+const (
+	_number             = `(?:\d+\.?\d*|\.\d+)(?:[eE][+-]?\d+)?`
+	_scientificNotation = 15
+	// _cancellation is how far below the terms it came from an error can
+	// cancel before what is left is only rounding.
+	_cancellation = 1e-14
+)
+
+var (
+	_plusMinusRe = regexp.MustCompile(`^([+-]?` + _number + `)\s*(?:±|\+/-|\+-)\s*(` + _number + `)$`)
+	_conciseRe   = regexp.MustCompile(`^([+-]?\d+(?:\.(\d+))?)\((\d+)\)([eE][+-]?\d+)?$`)
+
+	_uncertainSources atomic.Int64
+)
+
+type Uncertain struct {
+	value float64
+	// terms maps each independent source to the error it contributes, its
+	// uncertainty times the sensitivity of value to it. It is never mutated
+	// once built, so copies of a value share it safely.
+	terms map[int64]float64
+}
+
+type UncertainConfig struct {
+	plusMinus bool
+	constants bool
+}
+
+// NewUncertain makes a value with its own independent uncertainty sigma.
+func NewUncertain(value, sigma float64) Uncertain {
+	if sigma == 0 {
+		return Uncertain{value: value}
+	}
+	return Uncertain{value, map[int64]float64{_uncertainSources.Add(1): sigma}}
+}
+
+func (u Uncertain) Sigma() float64 {
+	total := 0.0
+	for _, term := range u.terms {
+		total += term * term
+	}
+	return math.Sqrt(total)
+}
+
+func (u Uncertain) Format(format func(float64) string) string {
+	return u.notation(false)
+}
+
+// notation rounds the uncertainty to one significant figure, or two when
+// its first digit is 1, and the value to the same decimal place. Values too
+// large or small for fixed point share an exponent.
+func (u Uncertain) notation(plusMinus bool) string {
+	sigma := u.Sigma()
+	if sigma == 0 || math.IsNaN(sigma) || math.IsInf(sigma, 0) || math.IsNaN(u.value) || math.IsInf(u.value, 0) {
+		if plusMinus {
+			return fmt.Sprintf("%g ± %g", u.value, sigma)
+		}
+		return fmt.Sprintf("%g(%g)", u.value, sigma)
+	}
+	exponent := 0
+	if u.value != 0 {
+		exponent = int(math.Floor(math.Log10(math.Abs(u.value))))
+	}
+	if exponent < -4 || exponent > _scientificNotation {
+		scale := math.Pow10(exponent)
+		mantissa := Uncertain{u.value / scale, map[int64]float64{0: sigma / scale}}
+		if plusMinus {
+			return fmt.Sprintf("(%s)e%d", mantissa.notation(true), exponent)
+		}
+		return fmt.Sprintf("%se%d", mantissa.notation(false), exponent)
+	}
+	place := int(math.Floor(math.Log10(sigma)))
+	if sigma/math.Pow10(place) < 2 {
+		place--
+	}
+	digits := math.Round(sigma / math.Pow10(place))
+	decimals := max(0, -place)
+	value := strconv.FormatFloat(math.Round(u.value/math.Pow10(place))*math.Pow10(place), 'f', decimals, 64)
+	if plusMinus {
+		return fmt.Sprintf("%s ± %s", value, strconv.FormatFloat(digits*math.Pow10(place), 'f', decimals, 64))
+	}
+	if place > 0 {
+		digits *= math.Pow10(place)
+	}
+	return fmt.Sprintf("%s(%.0f)", value, digits)
+}
+
+// FormatValue shows an Uncertain value in the chosen notation and hands
+// anything else to format.
+func (c UncertainConfig) FormatValue(v Value, format func(Value) string) string {
+	if u, ok := v.(Uncertain); ok {
+		return u.notation(c.plusMinus)
+	}
+	return format(v)
+}
+
+// parseUncertain reads 9.81±0.02, 9.81+-0.02, 9.81+/-0.02 and 9.81(2).
+func parseUncertain(text string) (Uncertain, error) {
+	if match := _plusMinusRe.FindStringSubmatch(text); match != nil {
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return Uncertain{}, err
+		}
+		sigma, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return Uncertain{}, err
+		}
+		return NewUncertain(value, sigma), nil
+	}
+	if match := _conciseRe.FindStringSubmatch(text); match != nil {
+		value, err := strconv.ParseFloat(match[1]+match[4], 64)
+		if err != nil {
+			return Uncertain{}, err
+		}
+		digits, err := strconv.ParseFloat(match[3], 64)
+		if err != nil {
+			return Uncertain{}, err
+		}
+		exponent := -len(match[2])
+		if len(match[4]) > 0 {
+			shift, err := strconv.Atoi(strings.TrimLeft(match[4][1:], "+"))
+			if err != nil {
+				return Uncertain{}, err
+			}
+			exponent += shift
+		}
+		return NewUncertain(value, digits*math.Pow10(exponent)), nil
+	}
+	return Uncertain{}, fmt.Errorf("'%s' is not an uncertain value", text)
+}
+
+func tryUncertain(line string, stack *Stack) error {
+	u, err := parseUncertain(line)
+	if err != nil {
+		return err
+	}
+	stack.PushValue(u.reduce())
+	return nil
+}
+
+// uncertainOf reads any number as an Uncertain, exact unless it is one.
+func uncertainOf(v Value) (Uncertain, bool) {
+	if u, ok := v.(Uncertain); ok {
+		return u, true
+	}
+	if x, ok := scalarOf(v); ok {
+		return Uncertain{value: x}, true
+	}
+	return Uncertain{}, false
+}
+
+// combine is the linear propagation of two values' errors into a result
+// with the partial derivatives dx and dy. A term that cancels to within
+// rounding is dropped, and a result with no terms left is an exact Scalar,
+// as for x - x.
+func combine(value float64, x Uncertain, dx float64, y Uncertain, dy float64) Value {
+	terms := make(map[int64]float64, len(x.terms)+len(y.terms))
+	sizes := make(map[int64]float64, len(x.terms)+len(y.terms))
+	for source, term := range x.terms {
+		terms[source] += dx * term
+		sizes[source] += math.Abs(dx * term)
+	}
+	for source, term := range y.terms {
+		terms[source] += dy * term
+		sizes[source] += math.Abs(dy * term)
+	}
+	for source, term := range terms {
+		if math.Abs(term) <= _cancellation*sizes[source] {
+			delete(terms, source)
+		}
+	}
+	return Uncertain{value, terms}.reduce()
+}
+
+// reduce turns an Uncertain without any error into a plain Scalar.
+func (u Uncertain) reduce() Value {
+	if len(u.terms) == 0 {
+		return Scalar(u.value)
+	}
+	return u
+}
+
+// propagate applies f to a value and scales its errors by the derivative.
+// It fails where the derivative is not finite, as at the edge of the domain
+// of sqrt, since first order propagation says nothing there.
+func propagate(u Uncertain, f func(float64) float64) (Value, error) {
+	slope, err := slopeAt(f, u)
+	if err != nil {
+		return nil, err
+	}
+	return combine(f(u.value), u, slope, Uncertain{}, 0), nil
+}
+
+// slopeAt is the derivative of f at u, or zero when u is exact.
+func slopeAt(f func(float64) float64, u Uncertain) (float64, error) {
+	if len(u.terms) == 0 {
+		return 0, nil
+	}
+	slope, err := derivative(func(x float64) (float64, error) { return f(x), nil }, u.value)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(slope) || math.IsInf(slope, 0) {
+		return 0, fmt.Errorf("cannot propagate an uncertainty through %g, the derivative there is not finite", u.value)
+	}
+	return slope, nil
+}
+
+// uncertainArith propagates through any binary f with numerical partial
+// derivatives. It reports false unless an operand is Uncertain and both are
+// numbers.
+func uncertainArith(x, y Value, f func(float64, float64) float64) (Value, bool, error) {
+	_, xIsUncertain := x.(Uncertain)
+	_, yIsUncertain := y.(Uncertain)
+	if !xIsUncertain && !yIsUncertain {
+		return nil, false, nil
+	}
+	xu, ok := uncertainOf(x)
+	if !ok {
+		return nil, false, nil
+	}
+	yu, ok := uncertainOf(y)
+	if !ok {
+		return nil, false, nil
+	}
+	dx, err := slopeAt(func(t float64) float64 { return f(t, yu.value) }, xu)
+	if err != nil {
+		return nil, true, err
+	}
+	dy, err := slopeAt(func(t float64) float64 { return f(xu.value, t) }, yu)
+	if err != nil {
+		return nil, true, err
+	}
+	return combine(f(xu.value, yu.value), xu, dx, yu, dy), true, nil
+}
+
+// uncertainHook propagates with the exact partial derivatives of the
+// arithmetic operators.
+func uncertainHook(f func(x, y float64) (value, dx, dy float64)) arithHook {
+	return func(stack *Stack, x, y Value) (Value, bool, error) {
+		_, xIsUncertain := x.(Uncertain)
+		_, yIsUncertain := y.(Uncertain)
+		if !xIsUncertain && !yIsUncertain {
+			return nil, false, nil
+		}
+		xu, ok := uncertainOf(x)
+		if !ok {
+			return nil, false, nil
+		}
+		yu, ok := uncertainOf(y)
+		if !ok {
+			return nil, false, nil
+		}
+		value, dx, dy := f(xu.value, yu.value)
+		return combine(value, xu, dx, yu, dy), true, nil
+	}
+}
+
+func uncertainAdd(x, y float64) (float64, float64, float64) {
+	return x + y, 1, 1
+}
+
+func uncertainSub(x, y float64) (float64, float64, float64) {
+	return x - y, 1, -1
+}
+
+func uncertainMul(x, y float64) (float64, float64, float64) {
+	return x * y, y, x
+}
+
+func uncertainDiv(x, y float64) (float64, float64, float64) {
+	return x / y, 1 / y, -x / (y * y)
+}
+
+var (
+	plusMinusOp = Op{
+		"x s pm, the value x with standard uncertainty s",
+		func(stack *Stack) (Floats, error) {
+			elems, err := stack.PopR(2)
+			if err != nil {
+				return nil, err
+			}
+			if elems[1] < 0 {
+				return nil, fmt.Errorf("an uncertainty cannot be negative")
+			}
+			stack.PushValue(NewUncertain(elems[0], elems[1]).reduce())
+			return nil, nil
+		},
+	}
+
+	splitUncertainOp = Op{
+		"u unc, split an uncertain value into its value and its uncertainty, uncertainty on top",
+		func(stack *Stack) (Floats, error) {
+			top, err := stack.PopValue()
+			if err != nil {
+				return nil, err
+			}
+			u, ok := uncertainOf(top)
+			if !ok {
+				return nil, fmt.Errorf("expected a number but found %s", describeKind(top))
+			}
+			return Floats{u.Sigma(), u.value}, nil
+		},
+	}
+
+	plusMinusDisplayOp = Op{
+		"show uncertain values as 9.81 ± 0.02",
+		func(stack *Stack) (Floats, error) {
+			stack.uncertain.plusMinus = true
+			return nil, nil
+		},
+	}
+
+	conciseDisplayOp = Op{
+		"show uncertain values as 9.81(2)",
+		func(stack *Stack) (Floats, error) {
+			stack.uncertain.plusMinus = false
+			return nil, nil
+		},
+	}
+
+	uncertainConstantsOp = Op{
+		"push CODATA constants with their uncertainty",
+		func(stack *Stack) (Floats, error) {
+			stack.uncertain.constants = true
+			return nil, nil
+		},
+	}
+
+	exactConstantsOp = Op{
+		"push CODATA constants as plain numbers",
+		func(stack *Stack) (Floats, error) {
+			stack.uncertain.constants = false
+			return nil, nil
+		},
+	}
+)
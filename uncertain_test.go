@@ -0,0 +1,223 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func popUncertain(t *testing.T, stack *Stack) Uncertain {
+	t.Helper()
+	v, err := stack.PopValue()
+	assert.Nil(t, err)
+	u, ok := v.(Uncertain)
+	assert.True(t, ok, kindOf(v))
+	return u
+}
+
+func TestParseUncertain(t *testing.T) {
+	cases := []struct {
+		text  string
+		value float64
+		sigma float64
+	}{
+		{"9.81±0.02", 9.81, 0.02},
+		{"9.81 ± 0.02", 9.81, 0.02},
+		{"9.81+-0.02", 9.81, 0.02},
+		{"-9.81+/-2e-2", -9.81, 0.02},
+		{"9.81(2)", 9.81, 0.02},
+		{"1.0973(15)", 1.0973, 0.0015},
+		{"6.67430(15)e-11", 6.67430e-11, 0.00015e-11},
+		{"1234(56)", 1234, 56},
+	}
+	for _, c := range cases {
+		u, err := parseUncertain(c.text)
+		assert.Nil(t, err, c.text)
+		assert.InEpsilon(t, c.value, u.value, 1e-12, c.text)
+		assert.InEpsilon(t, c.sigma, u.Sigma(), 1e-12, c.text)
+	}
+
+	for _, text := range []string{"9.81", "9.81±", "±0.02", "9.81(2", "9.81±-0.02"} {
+		_, err := parseUncertain(text)
+		assert.NotNil(t, err, text)
+	}
+}
+
+func TestUncertainNotation(t *testing.T) {
+	cases := []struct {
+		value   float64
+		sigma   float64
+		concise string
+		pm      string
+	}{
+		{9.81, 0.02, "9.81(2)", "9.81 ± 0.02"},
+		{9.8123, 0.0234, "9.81(2)", "9.81 ± 0.02"},
+		{9.8123, 0.0123, "9.812(12)", "9.812 ± 0.012"},
+		{1234, 56, "1230(60)", "1230 ± 60"},
+		{-0.5, 0.25, "-0.5(3)", "-0.5 ± 0.3"},
+		{6.67430e-11, 0.00015e-11, "6.67430(15)e-11", "(6.67430 ± 0.00015)e-11"},
+		{42, 0, "42(0)", "42 ± 0"},
+	}
+	for _, c := range cases {
+		u := NewUncertain(c.value, c.sigma)
+		assert.Equal(t, c.concise, u.notation(false))
+		assert.Equal(t, c.pm, u.notation(true))
+	}
+}
+
+func TestUncertainArithmetic(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	// independent errors add in quadrature
+	runAll(t, stack, ops, "10±0.3", "20±0.4", "+")
+	u := popUncertain(t, stack)
+	assert.InDelta(t, 30, u.value, 1e-12)
+	assert.InDelta(t, 0.5, u.Sigma(), 1e-12)
+
+	runAll(t, stack, ops, "10±0.3", "20±0.4", "-")
+	assert.InDelta(t, 0.5, popUncertain(t, stack).Sigma(), 1e-12)
+
+	// relative errors of a product add in quadrature
+	runAll(t, stack, ops, "10±0.3", "20±0.4", "*")
+	u = popUncertain(t, stack)
+	assert.InDelta(t, 200, u.value, 1e-12)
+	assert.InDelta(t, 200*math.Hypot(0.03, 0.02), u.Sigma(), 1e-12)
+
+	runAll(t, stack, ops, "10±0.3", "20±0.4", "/")
+	u = popUncertain(t, stack)
+	assert.InDelta(t, 0.5, u.value, 1e-12)
+	assert.InDelta(t, 0.5*math.Hypot(0.03, 0.02), u.Sigma(), 1e-12)
+
+	// an exact operand scales the error
+	runAll(t, stack, ops, "9.81±0.02", "3", "*")
+	assert.InDelta(t, 0.06, popUncertain(t, stack).Sigma(), 1e-12)
+}
+
+func TestUncertainCorrelation(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	// dup * is x², whose relative error is twice that of x
+	runAll(t, stack, ops, "9.81±0.02", "dup", "*")
+	u := popUncertain(t, stack)
+	assert.InDelta(t, 9.81*9.81, u.value, 1e-12)
+	assert.InDelta(t, 2*9.81*0.02, u.Sigma(), 1e-12)
+
+	// two separate measurements multiply in quadrature
+	runAll(t, stack, ops, "9.81±0.02", "9.81±0.02", "*")
+	assert.InDelta(t, math.Sqrt2*9.81*0.02, popUncertain(t, stack).Sigma(), 1e-12)
+
+	// an error that cancels leaves an exact number
+	runAll(t, stack, ops, "9.81±0.02", "dup", "-")
+	assert.Equal(t, "[ 0 ]", stack.String())
+	stack.Clear()
+
+	runAll(t, stack, ops, "9.81±0.02", "dup", "dup", "+", "/")
+	assert.Equal(t, "[ 0.5 ]", stack.String())
+	stack.Clear()
+
+	runAll(t, stack, ops, "9.81±0", "2", "0", "pm")
+	assert.Equal(t, "[ 9.81  2 ]", stack.String())
+}
+
+func TestUncertainMath(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	runAll(t, stack, ops, "4±0.2", "sqrt")
+	u := popUncertain(t, stack)
+	assert.InDelta(t, 2, u.value, 1e-12)
+	assert.InDelta(t, 0.05, u.Sigma(), 1e-9)
+
+	runAll(t, stack, ops, "0.5±0.01", "sin")
+	u = popUncertain(t, stack)
+	assert.InDelta(t, math.Sin(0.5), u.value, 1e-12)
+	assert.InDelta(t, math.Cos(0.5)*0.01, u.Sigma(), 1e-9)
+
+	// binary wrappers take numerical partial derivatives
+	runAll(t, stack, ops, "3±0.1", "4±0.1", "hypot")
+	u = popUncertain(t, stack)
+	assert.InDelta(t, 5, u.value, 1e-12)
+	assert.InDelta(t, math.Hypot(0.6*0.1, 0.8*0.1), u.Sigma(), 1e-9)
+
+	// first order propagation fails at the edge of a domain
+	runAll(t, stack, ops, "0±0.1")
+	assert.NotNil(t, ops.Run("sqrt", stack))
+	assert.Equal(t, "[ 0.00(10) ]", stack.String())
+	stack.Clear()
+
+	// other ops refuse to drop the uncertainty
+	for _, line := range [][]string{{"9.81±0.02", "fc"}, {"2±0.1", "!"}, {"100±1", "30", "tdil"}, {"[1 2]", "1±0.1", "+"}} {
+		runAll(t, stack, ops, line[:len(line)-1]...)
+		before := stack.String()
+		assert.NotNil(t, cascade(line[len(line)-1], stack, ops), "%v", line)
+		assert.Equal(t, before, stack.String())
+		stack.Clear()
+	}
+	runAll(t, stack, ops, "9.81±0.02")
+	_, err := stack.Pop()
+	assert.EqualError(t, err, "expected a scalar but found an uncertain value")
+	assert.Equal(t, "an 8-vector", describeKind(make(Vector, 8)))
+	assert.Equal(t, "a 2x3 matrix", describeKind(NewMatrix(2, 3)))
+}
+
+func TestUncertainOps(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	runAll(t, stack, ops, "9.81", "0.02", "pm", "unc")
+	assert.InDelta(t, 0.02, stack.PopU(), 1e-12)
+	assert.InDelta(t, 9.81, stack.PopU(), 1e-12)
+
+	stack.Push(1)
+	stack.Push(-1)
+	assert.NotNil(t, ops.Run("pm", stack))
+	assert.Equal(t, 2, stack.Len())
+	stack.Clear()
+
+	runAll(t, stack, ops, "9.81±0.02", "1.5", "2±0.1")
+	assert.Equal(t, "[ 9.81(2)  1.5  2.00(10) ]", stack.String())
+	runAll(t, stack, ops, "upm")
+	assert.Equal(t, "[ 9.81 ± 0.02  1.5  2.00 ± 0.10 ]", stack.String())
+	runAll(t, stack, ops, "uparen")
+	assert.Equal(t, "[ 9.81(2)  1.5  2.00(10) ]", stack.String())
+
+	// decimal mode keeps the uncertain notation
+	stack.Clear()
+	runAll(t, stack, ops, "dec", "9.81±0.02", "1.5", "upm")
+	assert.Equal(t, "[ 9.81 ± 0.02  1.50 ]", stack.String())
+}
+
+func TestUncertainConstants(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+
+	runAll(t, stack, ops, "const G")
+	assert.Equal(t, 6.67430e-11, stack.PopU())
+
+	runAll(t, stack, ops, "uconst", "const G")
+	u := popUncertain(t, stack)
+	assert.InEpsilon(t, 0.00015e-11, u.Sigma(), 1e-12)
+
+	// exact constants stay plain numbers
+	runAll(t, stack, ops, "const h")
+	assert.Equal(t, 6.62607015e-34, stack.PopU())
+
+	// every push of a constant is the same measurement
+	runAll(t, stack, ops, "const G", "const G", "/")
+	assert.Equal(t, "[ 1 ]", stack.String())
+	stack.Clear()
+
+	// and constants derived from the same measurement are correlated
+	runAll(t, stack, ops, "const eps0", "const mu0", "*", "const c", "dup", "*", "*")
+	runAll(t, stack, ops, "const re", "const a0", "/", "const alpha", "dup", "*", "/")
+	results := scalars(t, stack)
+	assert.InDelta(t, 1, results[0], 1e-12)
+	assert.InDelta(t, 1, results[1], 1e-11)
+	stack.Clear()
+
+	runAll(t, stack, ops, "nouconst", "const G")
+	assert.Equal(t, 6.67430e-11, stack.PopU())
+}
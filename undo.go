@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// History keeps snapshots of the stack taken before each line that changed
+// it. A snapshot holds the values and every register and setting an op can
+// change, so undo also takes back a store to pv or a Σ+. The random number
+// generator, the repeat state and the history itself are not part of it.
+type History struct {
+	snapshots []Snapshot
+}
+
+type Snapshot struct {
+	values    []Value
+	registers Registers
+}
+
+// Registers is the state beside the values that ops read and write.
+type Registers struct {
+	sigma        Sigma
+	quantileType int
+	display      Display
+	qconfig      QConfig
+	function     *Function
+	tvm          TVM
+	ballistics   Ballistics
+	decimal      DecimalConfig
+	zone         *time.Location
+	uncertain    UncertainConfig
+}
+
+const (
+	_maxUndo = 100
+)
+
+func sameValues(x, y []Value) bool {
+	return slices.EqualFunc(x, y, func(a, b Value) bool {
+		return reflect.DeepEqual(a, b)
+	})
+}
+
+func (s *Stack) Snapshot() Snapshot {
+	return Snapshot{
+		s.Values(),
+		Registers{
+			sigma:        s.sigma,
+			quantileType: s.quantileType,
+			display:      s.display,
+			qconfig:      s.qconfig,
+			function:     s.function,
+			tvm:          s.tvm,
+			ballistics:   s.ballistics,
+			decimal:      s.decimal,
+			zone:         s.zone,
+			uncertain:    s.uncertain,
+		},
+	}
+}
+
+func (s *Stack) Restore(snapshot Snapshot) {
+	s.storage = snapshot.values
+	r := snapshot.registers
+	s.sigma = r.sigma
+	s.quantileType = r.quantileType
+	s.display = r.display
+	s.qconfig = r.qconfig
+	s.function = r.function
+	s.tvm = r.tvm
+	s.ballistics = r.ballistics
+	s.decimal = r.decimal
+	s.zone = r.zone
+	s.uncertain = r.uncertain
+}
+
+func (h *History) Record(before, after Snapshot) {
+	if sameValues(before.values, after.values) && before.registers == after.registers {
+		return
+	}
+	h.snapshots = append(h.snapshots, before)
+	if len(h.snapshots) > _maxUndo {
+		h.snapshots = h.snapshots[1:]
+	}
+}
+
+func (h *History) Undo() (Snapshot, error) {
+	size := len(h.snapshots)
+	if size < 1 {
+		return Snapshot{}, fmt.Errorf("nothing to undo")
+	}
+	snapshot := h.snapshots[size-1]
+	h.snapshots = h.snapshots[:size-1]
+	return snapshot, nil
+}
+
+var (
+	undoOp = Op{
+		"restore the stack and its registers as they were before the previous line",
+		func(stack *Stack) (Floats, error) {
+			snapshot, err := stack.history.Undo()
+			if err != nil {
+				return nil, err
+			}
+			stack.Restore(snapshot)
+			return nil, nil
+		},
+	}
+)
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndo(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	assert.NotNil(t, ops.Run("undo", stack))
+
+	assert.Nil(t, cascade("2", stack, ops))
+	assert.Nil(t, cascade("3", stack, ops))
+	assert.Nil(t, cascade("*", stack, ops))
+	assert.Equal(t, []float64{6}, scalars(t, stack))
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, []float64{2, 3}, scalars(t, stack))
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, []float64{2}, scalars(t, stack))
+
+	assert.Nil(t, cascade("dfrac", stack, ops))
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, DisplayStandard, stack.display.mode)
+	assert.Equal(t, []float64{2}, scalars(t, stack))
+}
+
+func TestUndoRegisters(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	runAll(t, stack, ops, "100", "pv")
+	assert.Equal(t, 100.0, stack.tvm.pv)
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, 0.0, stack.tvm.pv)
+	assert.Equal(t, []float64{100}, scalars(t, stack))
+
+	runAll(t, stack, ops, "2", "Σ+")
+	assert.Equal(t, 1.0, stack.sigma.n)
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, 0.0, stack.sigma.n)
+
+	runAll(t, stack, ops, "dec")
+	assert.True(t, stack.decimal.enabled)
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.False(t, stack.decimal.enabled)
+
+	runAll(t, stack, ops, "2900", "mv")
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, NewBallistics(), stack.ballistics)
+}
+
+func TestUndoRepeat(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	stack.Push(1)
+	assert.Nil(t, cascade("10 x ++", stack, ops))
+	assert.Nil(t, cascade("", stack, ops))
+	assert.Equal(t, []float64{21}, scalars(t, stack))
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, []float64{11}, scalars(t, stack))
+	assert.Nil(t, cascade("undo", stack, ops))
+	assert.Equal(t, []float64{1}, scalars(t, stack))
+}
+
+func TestUndoLimit(t *testing.T) {
+	stack := NewStack()
+	ops := NewOps()
+	for range _maxUndo + 10 {
+		assert.Nil(t, cascade("1", stack, ops))
+	}
+	for range _maxUndo {
+		assert.Nil(t, ops.Run("undo", stack))
+	}
+	assert.Equal(t, 10, stack.Len())
+	assert.NotNil(t, ops.Run("undo", stack))
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value is anything that can live on the Stack. Plain numbers are Scalars;
+// everything else is pushed and popped with PushValue and PopValue, while
+// Push, Pop and friends keep working in float64 for the scalar ops.
+type Value interface {
+	Format(format func(float64) string) string
+}
+
+type (
+	Scalar float64
+
+	Vector []float64
+
+	// Matrix is stored row-major.
+	Matrix struct {
+		rows int
+		cols int
+		data []float64
+	}
+)
+
+func (s Scalar) Format(format func(float64) string) string {
+	return format(float64(s))
+}
+
+func (v Vector) Format(format func(float64) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[")
+	for i, n := range v {
+		if i > 0 {
+			fmt.Fprintf(&b, " ")
+		}
+		fmt.Fprint(&b, format(n))
+	}
+	fmt.Fprintf(&b, "]")
+	return b.String()
+}
+
+func NewMatrix(rows, cols int) Matrix {
+	return Matrix{rows, cols, make([]float64, rows*cols)}
+}
+
+func (m Matrix) At(i, j int) float64 {
+	return m.data[i*m.cols+j]
+}
+
+func (m Matrix) Set(i, j int, value float64) {
+	m.data[i*m.cols+j] = value
+}
+
+func (m Matrix) Row(i int) Vector {
+	return Vector(m.data[i*m.cols : (i+1)*m.cols])
+}
+
+func (m Matrix) Format(format func(float64) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[")
+	for i := range m.rows {
+		fmt.Fprint(&b, m.Row(i).Format(format))
+	}
+	fmt.Fprintf(&b, "]")
+	return b.String()
+}
+
+func (m Matrix) clone() Matrix {
+	result := NewMatrix(m.rows, m.cols)
+	copy(result.data, m.data)
+	return result
+}
+
+// scalarOf reads a single number, exact or not, as a float64. An Uncertain
+// value is not read, since its uncertainty would be silently dropped; ops
+// that handle one do so with uncertainOf.
+func scalarOf(v Value) (float64, bool) {
+	switch t := v.(type) {
+	case Scalar:
+		return float64(t), true
+	case Decimal:
+		return t.Float(), true
+	}
+	return 0, false
+}
+
+// elements returns the numbers a value is made of, in row-major order.
+func elements(v Value) []float64 {
+	switch t := v.(type) {
+	case Scalar:
+		return []float64{float64(t)}
+	case Decimal:
+		return []float64{t.Float()}
+	case Vector:
+		return t
+	case Matrix:
+		return t.data
+	}
+	return nil
+}
+
+func kindOf(v Value) string {
+	switch t := v.(type) {
+	case Scalar:
+		return "scalar"
+	case Vector:
+		return fmt.Sprintf("%d-vector", len(t))
+	case Matrix:
+		return fmt.Sprintf("%dx%d matrix", t.rows, t.cols)
+	case Complex:
+		return "complex"
+	case Decimal:
+		return "decimal"
+	case Time:
+		if t.dateOnly {
+			return "date"
+		}
+		return "time"
+	case Duration:
+		return "duration"
+	case Uncertain:
+		return "uncertain value"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// describeKind is kindOf with its article, as in an uncertain value or an
+// 8-vector.
+func describeKind(v Value) string {
+	kind := kindOf(v)
+	digits := strings.IndexFunc(kind, func(r rune) bool { return r < '0' || r > '9' })
+	number := kind[:max(digits, 0)]
+	switch {
+	case strings.ContainsRune("aeiou", rune(kind[0])),
+		strings.HasPrefix(number, "8"),
+		len(number)%3 == 2 && (strings.HasPrefix(number, "11") || strings.HasPrefix(number, "18")):
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+func mapValue(v Value, f func(float64) float64) (Value, error) {
+	switch t := v.(type) {
+	case Scalar:
+		return Scalar(f(float64(t))), nil
+	case Decimal:
+		return Scalar(f(t.Float())), nil
+	case Uncertain:
+		return propagate(t, f)
+	case Vector:
+		result := make(Vector, len(t))
+		for i, n := range t {
+			result[i] = f(n)
+		}
+		return result, nil
+	case Matrix:
+		result := NewMatrix(t.rows, t.cols)
+		for i, n := range t.data {
+			result.data[i] = f(n)
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("cannot apply a numeric op to %s", describeKind(v))
+}
+
+// broadcast applies f element-wise. A scalar pairs with every element of the
+// other operand; vectors and matrices must have matching shapes.
+func broadcast(x, y Value, f func(float64, float64) float64) (Value, error) {
+	if xs, ok := scalarOf(x); ok {
+		return mapValue(y, func(n float64) float64 { return f(xs, n) })
+	}
+	if ys, ok := scalarOf(y); ok {
+		return mapValue(x, func(n float64) float64 { return f(n, ys) })
+	}
+	switch xt := x.(type) {
+	case Vector:
+		yt, ok := y.(Vector)
+		if ok && len(xt) == len(yt) {
+			result := make(Vector, len(xt))
+			for i := range xt {
+				result[i] = f(xt[i], yt[i])
+			}
+			return result, nil
+		}
+	case Matrix:
+		yt, ok := y.(Matrix)
+		if ok && xt.rows == yt.rows && xt.cols == yt.cols {
+			result := NewMatrix(xt.rows, xt.cols)
+			for i := range xt.data {
+				result.data[i] = f(xt.data[i], yt.data[i])
+			}
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot combine %s with %s", describeKind(x), describeKind(y))
+}
+
+// pushResult hands scalars back to Ops.Run as Floats and pushes anything else
+// directly.
+func pushResult(stack *Stack, v Value) (Floats, error) {
+	if s, ok := v.(Scalar); ok {
+		return Floats{float64(s)}, nil
+	}
+	stack.PushValue(v)
+	return nil, nil
+}
+
+// parseArray reads vector and matrix literals such as [1 2 3] and
+// [[1 2][3 4]]. Commas have already been stripped by the shell.
+func parseArray(line string) (Value, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return nil, fmt.Errorf("not an array literal")
+	}
+	inner := strings.TrimSpace(line[1 : len(line)-1])
+	if !strings.HasPrefix(inner, "[") {
+		return parseVector(inner)
+	}
+	rows := []Vector{}
+	for len(inner) > 0 {
+		if inner[0] != '[' {
+			return nil, fmt.Errorf("malformed matrix literal")
+		}
+		end := strings.IndexByte(inner, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated matrix row")
+		}
+		row, err := parseVector(inner[1:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("matrix rows must all have the same length")
+		}
+		rows = append(rows, row)
+		inner = strings.TrimSpace(inner[end+1:])
+	}
+	result := NewMatrix(len(rows), len(rows[0]))
+	for i, row := range rows {
+		copy(result.data[i*result.cols:], row)
+	}
+	return result, nil
+}
+
+func parseVector(text string) (Vector, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 1 {
+		return nil, fmt.Errorf("empty array literal")
+	}
+	result := make(Vector, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+func tryArray(line string, stack *Stack) error {
+	value, err := parseArray(line)
+	if err != nil {
+		return err
+	}
+	stack.PushValue(value)
+	return nil
+}
+
+// toAny converts a value into the form expr expressions see in s.
+func toAny(v Value) any {
+	switch t := v.(type) {
+	case Scalar:
+		return float64(t)
+	case Decimal:
+		return t.Float()
+	case Uncertain:
+		return t.value
+	case Time:
+		return t.t
+	case Duration:
+		return time.Duration(t)
+	case Vector:
+		return []float64(t)
+	case Matrix:
+		rows := make([][]float64, t.rows)
+		for i := range t.rows {
+			rows[i] = t.Row(i)
+		}
+		return rows
+	}
+	return math.NaN()
+}
+
+// fromAny converts an expr result back into a stack value.
+func fromAny(output any) (Value, error) {
+	switch t := output.(type) {
+	case []any:
+		if len(t) < 1 {
+			return nil, fmt.Errorf("empty array")
+		}
+		if _, nested := t[0].([]any); nested {
+			rows := make([]Vector, 0, len(t))
+			for _, row := range t {
+				value, err := fromAny(row)
+				if err != nil {
+					return nil, err
+				}
+				vector, ok := value.(Vector)
+				if !ok || (len(rows) > 0 && len(vector) != len(rows[0])) {
+					return nil, fmt.Errorf("ragged matrix")
+				}
+				rows = append(rows, vector)
+			}
+			result := NewMatrix(len(rows), len(rows[0]))
+			for i, row := range rows {
+				copy(result.data[i*result.cols:], row)
+			}
+			return result, nil
+		}
+		result := make(Vector, 0, len(t))
+		for _, elem := range t {
+			value, err := fromAny(elem)
+			if err != nil {
+				return nil, err
+			}
+			scalar, ok := value.(Scalar)
+			if !ok {
+				return nil, fmt.Errorf("ragged array")
+			}
+			result = append(result, float64(scalar))
+		}
+		return result, nil
+	case []float64:
+		return Vector(t), nil
+	}
+	value, err := strconv.ParseFloat(fmt.Sprintf("%v", output), 64)
+	if err != nil {
+		return nil, err
+	}
+	return Scalar(value), nil
+}